@@ -0,0 +1,449 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// BranchCleanupActionValue represents what a branch cleanup does with a
+// branch.
+type BranchCleanupActionValue string
+
+// List of available branch cleanup actions.
+const (
+	// BranchCleanupDelete deletes a branch that was merged long enough ago.
+	BranchCleanupDelete BranchCleanupActionValue = "delete"
+
+	// BranchCleanupStale flags a branch without recent commits. Stale
+	// branches are reported, but not deleted.
+	BranchCleanupStale BranchCleanupActionValue = "stale"
+
+	// BranchCleanupSkip keeps a branch the policy would otherwise act on,
+	// because it is protected, excluded or used by an open merge request.
+	BranchCleanupSkip BranchCleanupActionValue = "skip"
+)
+
+// BranchCleanupCandidate represents a branch a cleanup policy applies to.
+type BranchCleanupCandidate struct {
+	Project interface{}
+	Branch  *Branch
+	Action  BranchCleanupActionValue
+	Reason  string
+
+	// MergedAt is when the branch was merged, taken from its last merged
+	// merge request, or from its last commit if there is none. It is nil
+	// for branches that are not merged.
+	MergedAt *time.Time
+}
+
+// PlanBranchCleanupOptions represents the available PlanCleanup() options.
+type PlanBranchCleanupOptions struct {
+	// Projects are the IDs or paths of the projects to clean up. If empty,
+	// all projects the user is a member of are cleaned up.
+	Projects []interface{}
+
+	// Now is the time the ages are computed from. Defaults to the current
+	// time.
+	Now time.Time
+
+	// MergedFor deletes merged branches that were merged longer ago than
+	// this. Zero disables deleting merged branches.
+	MergedFor time.Duration
+
+	// StaleAfter flags unmerged branches without commits for longer than
+	// this. Zero disables flagging stale branches.
+	StaleAfter time.Duration
+
+	// Exclude are patterns of branch names that are never touched, like
+	// "release/*". The syntax is that of path.Match.
+	Exclude []string
+
+	// Concurrency limits the number of projects planned concurrently.
+	// Defaults to 1.
+	Concurrency int
+}
+
+// PlanCleanup applies the cleanup policy to the branches of the
+// given projects and returns the resulting report, without changing
+// anything. Branches the policy would act on, but that have to be kept,
+// are reported with BranchCleanupSkip. The default branch is never
+// reported.
+func (s *BranchesService) PlanCleanup(opt *PlanBranchCleanupOptions, options ...OptionFunc) ([]*BranchCleanupCandidate, error) {
+	if opt == nil {
+		opt = &PlanBranchCleanupOptions{}
+	}
+	for _, pattern := range opt.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+		}
+	}
+
+	projects := opt.Projects
+	if len(projects) == 0 {
+		var err error
+		if projects, err = listAllProjectIDs(s.client, options); err != nil {
+			return nil, err
+		}
+	}
+
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	candidates := make([][]*BranchCleanupCandidate, len(projects))
+	errs := make([]error, len(projects))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, pid := range projects {
+		wg.Add(1)
+		go func(i int, pid interface{}) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			candidates[i], errs[i] = s.planCleanup(pid, opt, options)
+		}(i, pid)
+	}
+	wg.Wait()
+
+	var all []*BranchCleanupCandidate
+	for i := range projects {
+		if errs[i] != nil {
+			return all, errs[i]
+		}
+		all = append(all, candidates[i]...)
+	}
+
+	return all, nil
+}
+
+func (s *BranchesService) planCleanup(pid interface{}, opt *PlanBranchCleanupOptions, options []OptionFunc) ([]*BranchCleanupCandidate, error) {
+	now := opt.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	p, _, err := s.client.Projects.GetProject(pid, nil, options...)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := s.listAllBranches(pid, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// The open merge requests are only needed if there is something to
+	// clean up, so they are listed lazily.
+	var openMRs map[string]int
+	inOpenMR := func(branch string) (int, error) {
+		if openMRs == nil {
+			if openMRs, err = s.listOpenMergeRequestBranches(pid, p.ID, options); err != nil {
+				return 0, err
+			}
+		}
+		return openMRs[branch], nil
+	}
+
+	var candidates []*BranchCleanupCandidate
+	for _, b := range branches {
+		if b.Name == p.DefaultBranch {
+			continue
+		}
+		c := &BranchCleanupCandidate{Project: pid, Branch: b}
+
+		var committed *time.Time
+		if b.Commit != nil {
+			committed = b.Commit.CommittedDate
+		}
+
+		switch {
+		case b.Merged && opt.MergedFor > 0:
+			if c.MergedAt, err = s.branchMergedAt(pid, b, options); err != nil {
+				return nil, err
+			}
+			if c.MergedAt == nil || now.Sub(*c.MergedAt) < opt.MergedFor {
+				continue
+			}
+			c.Action = BranchCleanupDelete
+			c.Reason = fmt.Sprintf("merged %s ago", formatDays(now.Sub(*c.MergedAt)))
+		case !b.Merged && opt.StaleAfter > 0:
+			if committed == nil || now.Sub(*committed) < opt.StaleAfter {
+				continue
+			}
+			c.Action = BranchCleanupStale
+			c.Reason = fmt.Sprintf("no commits for %s", formatDays(now.Sub(*committed)))
+		default:
+			continue
+		}
+
+		skip := ""
+		switch {
+		case b.Protected:
+			skip = "protected"
+		case matchesAny(b.Name, opt.Exclude):
+			skip = "excluded"
+		default:
+			iid, err := inOpenMR(b.Name)
+			if err != nil {
+				return nil, err
+			}
+			if iid > 0 {
+				skip = fmt.Sprintf("used by open merge request !%d", iid)
+			}
+		}
+		if skip != "" {
+			c.Action = BranchCleanupSkip
+			c.Reason += ", but " + skip
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	return candidates, nil
+}
+
+// branchMergedAt returns when the branch was merged by its last merged
+// merge request, or the time of its last commit.
+func (s *BranchesService) branchMergedAt(pid interface{}, b *Branch, options []OptionFunc) (*time.Time, error) {
+	mrs, _, err := s.client.MergeRequests.ListProjectMergeRequests(pid, &ListProjectMergeRequestsOptions{
+		ListOptions:  ListOptions{PerPage: 1},
+		State:        String("merged"),
+		SourceBranch: String(b.Name),
+		OrderBy:      String("updated_at"),
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+	if len(mrs) > 0 && mrs[0].MergedAt != nil {
+		return mrs[0].MergedAt, nil
+	}
+	if b.Commit != nil {
+		return b.Commit.CommittedDate, nil
+	}
+	return nil, nil
+}
+
+// listOpenMergeRequestBranches returns the IIDs of the open merge requests
+// by the branches of the project they use as source or target.
+func (s *BranchesService) listOpenMergeRequestBranches(pid interface{}, projectID int, options []OptionFunc) (map[string]int, error) {
+	branches := make(map[string]int)
+
+	opt := &ListProjectMergeRequestsOptions{ListOptions: ListOptions{Page: 1, PerPage: 100}, State: String("opened")}
+	for {
+		mrs, resp, err := s.client.MergeRequests.ListProjectMergeRequests(pid, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, mr := range mrs {
+			// The source branch of a merge request from a fork is not a
+			// branch of this project.
+			if mr.SourceProjectID == projectID || mr.SourceProjectID == 0 {
+				branches[mr.SourceBranch] = mr.IID
+			}
+			branches[mr.TargetBranch] = mr.IID
+		}
+
+		if resp.NextPage == 0 {
+			return branches, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func (s *BranchesService) listAllBranches(pid interface{}, options []OptionFunc) ([]*Branch, error) {
+	var branches []*Branch
+
+	opt := &ListBranchesOptions{Page: 1, PerPage: 100}
+	for {
+		bs, resp, err := s.ListBranches(pid, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, bs...)
+
+		if resp.NextPage == 0 {
+			return branches, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func formatDays(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// WriteBranchCleanupReport writes the candidates as a table, for review
+// before running BranchesService.Cleanup.
+func WriteBranchCleanupReport(w io.Writer, candidates []*BranchCleanupCandidate) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tBRANCH\tACTION\tREASON")
+	for _, c := range candidates {
+		fmt.Fprintf(tw, "%v\t%s\t%s\t%s\n", c.Project, c.Branch.Name, c.Action, c.Reason)
+	}
+	return tw.Flush()
+}
+
+// CleanupBranchesOptions represents the available Cleanup() options.
+type CleanupBranchesOptions struct {
+	// BatchSize is the number of branches deleted before pausing for
+	// BatchDelay. Defaults to 20.
+	BatchSize  int
+	BatchDelay time.Duration
+
+	// AuditLog receives a line for every branch that is deleted or fails
+	// to be deleted.
+	AuditLog io.Writer
+
+	// Now returns the time written to the audit log. Defaults to
+	// time.Now.
+	Now func() time.Time
+}
+
+// BranchCleanupResult represents the result of deleting a branch.
+type BranchCleanupResult struct {
+	Candidate *BranchCleanupCandidate
+	Deleted   bool
+	Err       error
+
+	// Reason tells why a branch that didn't fail was not deleted.
+	Reason string
+}
+
+// Cleanup deletes the branches of the candidates with the
+// BranchCleanupDelete action, usually as returned by PlanCleanup.
+// Branches that received new commits since they were planned are kept.
+//
+// Branches are deleted in batches. If a deletion in a batch fails, the
+// remaining batches are not started; the results so far and the first
+// error are returned.
+func (s *BranchesService) Cleanup(candidates []*BranchCleanupCandidate, opt *CleanupBranchesOptions, options ...OptionFunc) ([]*BranchCleanupResult, error) {
+	if opt == nil {
+		opt = &CleanupBranchesOptions{}
+	}
+	size := opt.BatchSize
+	if size <= 0 {
+		size = 20
+	}
+	now := opt.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	var deletes []*BranchCleanupCandidate
+	for _, c := range candidates {
+		if c.Action == BranchCleanupDelete {
+			deletes = append(deletes, c)
+		}
+	}
+
+	var results []*BranchCleanupResult
+	for start := 0; start < len(deletes); start += size {
+		if start > 0 && opt.BatchDelay > 0 {
+			time.Sleep(opt.BatchDelay)
+		}
+
+		end := start + size
+		if end > len(deletes) {
+			end = len(deletes)
+		}
+
+		var err error
+		for _, c := range deletes[start:end] {
+			r := s.deleteCleanupBranch(c, options)
+			results = append(results, r)
+
+			if opt.AuditLog != nil {
+				status := "deleted"
+				switch {
+				case r.Err != nil:
+					status = "failed: " + r.Err.Error()
+				case !r.Deleted:
+					status = "kept: " + r.Reason
+				}
+				fmt.Fprintf(opt.AuditLog, "%s project=%v branch=%s commit=%s reason=%q %s\n",
+					now().UTC().Format(time.RFC3339), c.Project, c.Branch.Name, branchCommitID(c.Branch), c.Reason, status)
+			}
+			if r.Err != nil && err == nil {
+				err = r.Err
+			}
+		}
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+func (s *BranchesService) deleteCleanupBranch(c *BranchCleanupCandidate, options []OptionFunc) *BranchCleanupResult {
+	r := &BranchCleanupResult{Candidate: c}
+
+	b, _, err := s.GetBranch(c.Project, c.Branch.Name, options...)
+	if isNotFound(err) {
+		r.Reason = "already deleted"
+		return r
+	}
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	if branchCommitID(b) != branchCommitID(c.Branch) {
+		r.Reason = "new commits since planned"
+		return r
+	}
+	if b.Protected {
+		r.Reason = "protected since planned"
+		return r
+	}
+
+	if _, err := s.DeleteBranch(c.Project, c.Branch.Name, options...); err != nil {
+		r.Err = err
+		return r
+	}
+	r.Deleted = true
+
+	return r
+}
+
+func branchCommitID(b *Branch) string {
+	if b.Commit == nil {
+		return ""
+	}
+	return b.Commit.ID
+}
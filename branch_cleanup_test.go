@@ -0,0 +1,125 @@
+package gitlab
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanAndCleanupBranches(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id": 1, "default_branch": "master"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/branches", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[
+			{"name": "master", "merged": false, "protected": true, "commit": {"id": "m", "committed_date": "2019-01-01T00:00:00Z"}},
+			{"name": "feature-old", "merged": true, "commit": {"id": "f1", "committed_date": "2019-01-01T00:00:00Z"}},
+			{"name": "feature-new", "merged": true, "commit": {"id": "f2", "committed_date": "2019-05-25T00:00:00Z"}},
+			{"name": "release/1.0", "merged": true, "commit": {"id": "r1", "committed_date": "2019-01-01T00:00:00Z"}},
+			{"name": "wip", "merged": false, "commit": {"id": "w1", "committed_date": "2019-02-01T00:00:00Z"}},
+			{"name": "hotfix", "merged": true, "commit": {"id": "h1", "committed_date": "2019-01-01T00:00:00Z"}}
+		]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		q := r.URL.Query()
+		switch {
+		case q.Get("state") == "opened":
+			fmt.Fprint(w, `[{"iid": 7, "source_project_id": 1, "source_branch": "wip", "target_branch": "master"}]`)
+		case q.Get("source_branch") == "feature-old":
+			fmt.Fprint(w, `[{"iid": 3, "merged_at": "2019-03-01T00:00:00Z"}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+
+	now := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	candidates, err := client.Branches.PlanCleanup(&PlanBranchCleanupOptions{
+		Projects:   []interface{}{1},
+		Now:        now,
+		MergedFor:  30 * 24 * time.Hour,
+		StaleAfter: 60 * 24 * time.Hour,
+		Exclude:    []string{"release/*"},
+	})
+	if err != nil {
+		t.Fatalf("Branches.PlanCleanup returned error: %v", err)
+	}
+
+	var report bytes.Buffer
+	if err := WriteBranchCleanupReport(&report, candidates); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"PROJECT  BRANCH       ACTION  REASON",
+		"1        feature-old  delete  merged 92 days ago",
+		"1        release/1.0  skip    merged 151 days ago, but excluded",
+		"1        wip          skip    no commits for 120 days, but used by open merge request !7",
+		"1        hotfix       delete  merged 151 days ago",
+		"",
+	}, "\n")
+	if report.String() != want {
+		t.Errorf("WriteBranchCleanupReport wrote:\n%s\nwant:\n%s", report.String(), want)
+	}
+
+	var deleted []string
+	mux.HandleFunc("/api/v4/projects/1/repository/branches/feature-old", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `{"name": "feature-old", "commit": {"id": "f1"}}`)
+		case "DELETE":
+			deleted = append(deleted, "feature-old")
+		}
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/branches/hotfix", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"name": "hotfix", "commit": {"id": "h2"}}`)
+	})
+
+	var audit bytes.Buffer
+	results, err := client.Branches.Cleanup(candidates, &CleanupBranchesOptions{
+		BatchSize: 1,
+		AuditLog:  &audit,
+		Now:       func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("Branches.Cleanup returned error: %v", err)
+	}
+
+	if len(results) != 2 || !results[0].Deleted || results[1].Deleted || len(deleted) != 1 {
+		t.Errorf("Branches.Cleanup returned %+v, deleted %v", results, deleted)
+	}
+	wantAudit := "2019-06-01T00:00:00Z project=1 branch=feature-old commit=f1 reason=\"merged 92 days ago\" deleted\n" +
+		"2019-06-01T00:00:00Z project=1 branch=hotfix commit=h1 reason=\"merged 151 days ago\" kept: new commits since planned\n"
+	if audit.String() != wantAudit {
+		t.Errorf("Branches.Cleanup audit log:\n%s\nwant:\n%s", audit.String(), wantAudit)
+	}
+}
+
+func TestCleanupBranchesStopsAfterFailedBatch(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/branches/a", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/branches/b", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request for branch b")
+	})
+
+	candidates := []*BranchCleanupCandidate{
+		{Project: 1, Branch: &Branch{Name: "a"}, Action: BranchCleanupDelete},
+		{Project: 1, Branch: &Branch{Name: "b"}, Action: BranchCleanupDelete},
+	}
+	results, err := client.Branches.Cleanup(candidates, &CleanupBranchesOptions{BatchSize: 1})
+	if err == nil || len(results) != 1 || results[0].Err == nil {
+		t.Errorf("Branches.Cleanup returned %+v, %v", results, err)
+	}
+}
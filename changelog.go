@@ -0,0 +1,331 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// conventionalCommitRegexp matches titles like "feat(api)!: add releases".
+var conventionalCommitRegexp = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s+(.+)$`)
+
+// ChangelogSection represents a section of a changelog. Entries are put in
+// the first section that has one of their labels, or their conventional
+// commit type.
+type ChangelogSection struct {
+	Title  string
+	Labels []string
+	Types  []string
+}
+
+// DefaultChangelogSections are the sections used if none are configured.
+var DefaultChangelogSections = []*ChangelogSection{
+	{Title: "Features", Labels: []string{"feature", "enhancement"}, Types: []string{"feat"}},
+	{Title: "Bug fixes", Labels: []string{"bug"}, Types: []string{"fix"}},
+	{Title: "Performance", Labels: []string{"performance"}, Types: []string{"perf"}},
+	{Title: "Documentation", Labels: []string{"documentation"}, Types: []string{"docs"}},
+}
+
+// ChangelogOptions represents the available GenerateChangelog() options.
+type ChangelogOptions struct {
+	// From and To are the refs to compare, usually the previous and the
+	// new tag.
+	From string
+	To   string
+
+	// Sections are the sections to group the entries in. Defaults to
+	// DefaultChangelogSections. Entries that match no section are put in
+	// a section titled OtherTitle, which defaults to "Other changes".
+	Sections   []*ChangelogSection
+	OtherTitle string
+
+	// MergeRequestsOnly leaves out commits that are not part of a merged
+	// merge request.
+	MergeRequestsOnly bool
+
+	// Concurrency limits the number of commits that are looked up
+	// concurrently. Defaults to 1.
+	Concurrency int
+}
+
+// ChangelogEntry represents a single change: a merged merge request, or a
+// commit that was pushed without one.
+type ChangelogEntry struct {
+	// Title is the title of the merge request or commit, without the
+	// conventional commit prefix.
+	Title string
+
+	// Type, Scope and Breaking are parsed from a conventional commit
+	// prefix of the title, like "feat(api)!: ".
+	Type     string
+	Scope    string
+	Breaking bool
+
+	MergeRequest *MergeRequest
+	Commits      []*Commit
+	Issues       []*Issue
+}
+
+// ChangelogGroup represents a section of a generated changelog.
+type ChangelogGroup struct {
+	Title   string
+	Entries []*ChangelogEntry
+}
+
+// Changelog represents the changes between two refs. Groups are in the
+// order of the configured sections, followed by the other changes; empty
+// groups are left out. Entries are in the order their first commit was
+// made.
+type Changelog struct {
+	From   string
+	To     string
+	Groups []*ChangelogGroup
+}
+
+// GenerateChangelog compares two refs and maps the commits between them to
+// their merged merge requests and the issues those closed, grouped by label
+// or conventional commit type.
+func (s *RepositoriesService) GenerateChangelog(pid interface{}, opt *ChangelogOptions, options ...OptionFunc) (*Changelog, error) {
+	if opt == nil || opt.From == "" || opt.To == "" {
+		return nil, fmt.Errorf("both From and To are required")
+	}
+
+	cmp, _, err := s.Compare(pid, &CompareOptions{From: String(opt.From), To: String(opt.To)}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	mrs := make([][]*MergeRequest, len(cmp.Commits))
+	errs := make([]error, len(cmp.Commits))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, c := range cmp.Commits {
+		wg.Add(1)
+		go func(i int, c *Commit) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			mrs[i], _, errs[i] = s.client.Commits.GetMergeRequestsByCommit(pid, c.ID, options...)
+		}(i, c)
+	}
+	wg.Wait()
+
+	var entries []*ChangelogEntry
+	byMR := make(map[int]*ChangelogEntry)
+
+	for i, c := range cmp.Commits {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		merged := false
+		for _, mr := range mrs[i] {
+			if mr.State != "merged" {
+				continue
+			}
+			merged = true
+			if e, ok := byMR[mr.IID]; ok {
+				e.Commits = append(e.Commits, c)
+				continue
+			}
+			e := newChangelogEntry(mr.Title, mr.Description)
+			e.MergeRequest = mr
+			e.Commits = []*Commit{c}
+			byMR[mr.IID] = e
+			entries = append(entries, e)
+		}
+
+		// Merge commits of merge requests that are not found are noise.
+		if merged || opt.MergeRequestsOnly || len(c.ParentIDs) > 1 {
+			continue
+		}
+		e := newChangelogEntry(c.Title, c.Message)
+		e.Commits = []*Commit{c}
+		entries = append(entries, e)
+	}
+
+	for _, e := range entries {
+		if e.MergeRequest == nil {
+			continue
+		}
+		e.Issues, err = s.client.MergeRequests.listAllIssuesClosedOnMerge(pid, e.MergeRequest.IID, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Changelog{From: opt.From, To: opt.To, Groups: groupChangelogEntries(entries, opt)}, nil
+}
+
+func newChangelogEntry(title, message string) *ChangelogEntry {
+	e := &ChangelogEntry{Title: title}
+	if m := conventionalCommitRegexp.FindStringSubmatch(title); m != nil {
+		e.Type = strings.ToLower(m[1])
+		e.Scope = m[2]
+		e.Breaking = m[3] == "!"
+		e.Title = m[4]
+	}
+	if strings.Contains(message, "BREAKING CHANGE:") || strings.Contains(message, "BREAKING-CHANGE:") {
+		e.Breaking = true
+	}
+	return e
+}
+
+func groupChangelogEntries(entries []*ChangelogEntry, opt *ChangelogOptions) []*ChangelogGroup {
+	sections := opt.Sections
+	if sections == nil {
+		sections = DefaultChangelogSections
+	}
+	other := opt.OtherTitle
+	if other == "" {
+		other = "Other changes"
+	}
+
+	groups := make([]*ChangelogGroup, len(sections)+1)
+	for i, s := range sections {
+		groups[i] = &ChangelogGroup{Title: s.Title}
+	}
+	groups[len(sections)] = &ChangelogGroup{Title: other}
+
+	for _, e := range entries {
+		i := changelogSection(e, sections)
+		groups[i].Entries = append(groups[i].Entries, e)
+	}
+
+	var result []*ChangelogGroup
+	for _, g := range groups {
+		if len(g.Entries) > 0 {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// changelogSection returns the index of the section of the entry, or the
+// number of sections for other changes.
+func changelogSection(e *ChangelogEntry, sections []*ChangelogSection) int {
+	var labels []string
+	if e.MergeRequest != nil {
+		labels = e.MergeRequest.Labels
+	}
+
+	for i, s := range sections {
+		for _, want := range s.Labels {
+			for _, l := range labels {
+				if strings.EqualFold(l, want) {
+					return i
+				}
+			}
+		}
+		for _, t := range s.Types {
+			if e.Type != "" && strings.EqualFold(e.Type, t) {
+				return i
+			}
+		}
+	}
+	return len(sections)
+}
+
+func (s *MergeRequestsService) listAllIssuesClosedOnMerge(pid interface{}, mergeRequest int, options []OptionFunc) ([]*Issue, error) {
+	var all []*Issue
+	opt := &GetIssuesClosedOnMergeOptions{Page: 1, PerPage: 100}
+	for {
+		issues, resp, err := s.GetIssuesClosedOnMerge(pid, mergeRequest, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, issues...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// Markdown renders the changelog as Markdown that can be used as the
+// description of a release. Every group is a level 3 heading with a list
+// of entries, which link to their merge request and closed issues.
+func (c *Changelog) Markdown() string {
+	var b bytes.Buffer
+	for i, g := range c.Groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "### %s\n\n", g.Title)
+		for _, e := range g.Entries {
+			b.WriteString("- ")
+			b.WriteString(e.Markdown())
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Markdown renders the entry as a single line of Markdown.
+func (e *ChangelogEntry) Markdown() string {
+	var b bytes.Buffer
+	if e.Breaking {
+		b.WriteString("**BREAKING** ")
+	}
+	if e.Scope != "" {
+		fmt.Fprintf(&b, "**%s:** ", e.Scope)
+	}
+	b.WriteString(e.Title)
+
+	var refs []string
+	switch {
+	case e.MergeRequest != nil:
+		refs = append(refs, markdownLink(fmt.Sprintf("!%d", e.MergeRequest.IID), e.MergeRequest.WebURL))
+	case len(e.Commits) > 0:
+		refs = append(refs, e.Commits[0].ShortID)
+	}
+	for i, issue := range e.Issues {
+		ref := markdownLink(fmt.Sprintf("#%d", issue.IID), issue.WebURL)
+		if i == 0 {
+			ref = "closes " + ref
+		}
+		refs = append(refs, ref)
+	}
+	if len(refs) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(refs, ", "))
+	}
+
+	if e.MergeRequest != nil && e.MergeRequest.Author.Username != "" {
+		fmt.Fprintf(&b, " by @%s", e.MergeRequest.Author.Username)
+	}
+
+	return b.String()
+}
+
+func markdownLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
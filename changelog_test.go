@@ -0,0 +1,101 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGenerateChangelog(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/compare", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if q := r.URL.Query(); q.Get("from") != "v1.0.0" || q.Get("to") != "v1.1.0" {
+			t.Errorf("Request query is %v", q)
+		}
+		fmt.Fprint(w, `{"commits": [
+			{"id": "a1", "short_id": "a1", "title": "feat(api): add releases", "parent_ids": ["p"]},
+			{"id": "a2", "short_id": "a2", "title": "Fix review comments", "parent_ids": ["a1"]},
+			{"id": "b1", "short_id": "b1", "title": "Handle empty tags", "parent_ids": ["a2"]},
+			{"id": "c1", "short_id": "c1", "title": "refactor!: drop Go 1.9", "parent_ids": ["b1"]},
+			{"id": "m1", "short_id": "m1", "title": "Merge branch 'x'", "parent_ids": ["c1", "b1"]}
+		]}`)
+	})
+
+	mrs := map[string]string{
+		"a1": `[{"iid": 1, "title": "feat(api): add releases", "state": "merged", "web_url": "https://example.com/mr/1", "author": {"username": "alice"}}]`,
+		"a2": `[{"iid": 1, "title": "feat(api): add releases", "state": "merged", "web_url": "https://example.com/mr/1", "author": {"username": "alice"}}]`,
+		"b1": `[{"iid": 2, "title": "Handle empty tags", "state": "merged", "labels": ["Bug"], "web_url": "https://example.com/mr/2"}, {"iid": 3, "state": "closed"}]`,
+		"c1": `[]`,
+		"m1": `[]`,
+	}
+	for sha, body := range mrs {
+		body := body
+		mux.HandleFunc("/api/v4/projects/1/repository/commits/"+sha+"/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			fmt.Fprint(w, body)
+		})
+	}
+	mux.HandleFunc("/api/v4/projects/1/merge_requests/1/closes_issues", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"iid": 10, "web_url": "https://example.com/issues/10"}, {"iid": 11}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/merge_requests/2/closes_issues", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+
+	changelog, err := client.Repositories.GenerateChangelog(1, &ChangelogOptions{From: "v1.0.0", To: "v1.1.0", Concurrency: 3})
+	if err != nil {
+		t.Fatalf("Repositories.GenerateChangelog returned error: %v", err)
+	}
+
+	if len(changelog.Groups) != 3 {
+		t.Fatalf("Repositories.GenerateChangelog returned %d groups, want 3", len(changelog.Groups))
+	}
+	if e := changelog.Groups[0].Entries[0]; e.Type != "feat" || e.Scope != "api" || len(e.Commits) != 2 || len(e.Issues) != 2 {
+		t.Errorf("Repositories.GenerateChangelog returned feature entry %+v", e)
+	}
+	if e := changelog.Groups[2].Entries[0]; !e.Breaking || e.MergeRequest != nil {
+		t.Errorf("Repositories.GenerateChangelog returned other entry %+v", e)
+	}
+
+	want := strings.Join([]string{
+		"### Features",
+		"",
+		"- **api:** add releases ([!1](https://example.com/mr/1), closes [#10](https://example.com/issues/10), #11) by @alice",
+		"",
+		"### Bug fixes",
+		"",
+		"- Handle empty tags ([!2](https://example.com/mr/2))",
+		"",
+		"### Other changes",
+		"",
+		"- **BREAKING** drop Go 1.9 (c1)",
+		"",
+	}, "\n")
+	if got := changelog.Markdown(); got != want {
+		t.Errorf("Changelog.Markdown returned:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestChangelogMergeRequestsOnly(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/compare", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"commits": [{"id": "c1", "title": "docs: fix typo"}]}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/commits/c1/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+
+	changelog, err := client.Repositories.GenerateChangelog(1, &ChangelogOptions{From: "v1", To: "v2", MergeRequestsOnly: true})
+	if err != nil {
+		t.Fatalf("Repositories.GenerateChangelog returned error: %v", err)
+	}
+	if len(changelog.Groups) != 0 || changelog.Markdown() != "" {
+		t.Errorf("Repositories.GenerateChangelog returned %+v", changelog.Groups)
+	}
+}
@@ -0,0 +1,781 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ciGlobalKeywords are the top-level keys of a .gitlab-ci.yml file that do
+// not define a job.
+var ciGlobalKeywords = map[string]bool{
+	"after_script":  true,
+	"before_script": true,
+	"cache":         true,
+	"default":       true,
+	"image":         true,
+	"include":       true,
+	"services":      true,
+	"stages":        true,
+	"types":         true,
+	"variables":     true,
+	"workflow":      true,
+}
+
+// CIConfig represents the content of a .gitlab-ci.yml file.
+//
+// YAML anchors, aliases and merge keys are resolved while parsing. Jobs keep
+// the order in which they are defined, and keys that are not part of the
+// model are preserved in the Extra fields, so a parsed configuration can be
+// modified and written back.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/
+type CIConfig struct {
+	Include      CIIncludes   `yaml:"include,omitempty"`
+	Stages       []string     `yaml:"stages,omitempty"`
+	Variables    CIVariables  `yaml:"variables,omitempty"`
+	Workflow     *CIWorkflow  `yaml:"workflow,omitempty"`
+	Default      *CIJob       `yaml:"default,omitempty"`
+	Image        *CIImage     `yaml:"image,omitempty"`
+	Services     []*CIImage   `yaml:"services,omitempty"`
+	BeforeScript CIStringList `yaml:"before_script,omitempty"`
+	AfterScript  CIStringList `yaml:"after_script,omitempty"`
+	Cache        *CICache     `yaml:"cache,omitempty"`
+
+	// Jobs contains all jobs, including hidden jobs whose name starts
+	// with a dot, in the order they are defined.
+	Jobs []*CIJob `yaml:"-"`
+
+	// Extra contains top-level keys that neither are a global keyword nor
+	// define a job.
+	Extra map[string]interface{} `yaml:"-"`
+}
+
+// CIJob represents a job in a .gitlab-ci.yml file. The same type is used
+// for the default section, which supports a subset of the job keywords.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#configuration-parameters
+type CIJob struct {
+	Name          string         `yaml:"-"`
+	Stage         string         `yaml:"stage,omitempty"`
+	Extends       CIStringList   `yaml:"extends,omitempty"`
+	Image         *CIImage       `yaml:"image,omitempty"`
+	Services      []*CIImage     `yaml:"services,omitempty"`
+	Tags          CIStringList   `yaml:"tags,omitempty"`
+	Variables     CIVariables    `yaml:"variables,omitempty"`
+	BeforeScript  CIStringList   `yaml:"before_script,omitempty"`
+	Script        CIStringList   `yaml:"script,omitempty"`
+	AfterScript   CIStringList   `yaml:"after_script,omitempty"`
+	Rules         []*CIRule      `yaml:"rules,omitempty"`
+	Only          *CIOnlyExcept  `yaml:"only,omitempty"`
+	Except        *CIOnlyExcept  `yaml:"except,omitempty"`
+	When          string         `yaml:"when,omitempty"`
+	StartIn       string         `yaml:"start_in,omitempty"`
+	AllowFailure  *bool          `yaml:"allow_failure,omitempty"`
+	Needs         CINeeds        `yaml:"needs,omitempty"`
+	Dependencies  CIStringList   `yaml:"dependencies,omitempty"`
+	Artifacts     *CIArtifacts   `yaml:"artifacts,omitempty"`
+	Cache         *CICache       `yaml:"cache,omitempty"`
+	Environment   *CIEnvironment `yaml:"environment,omitempty"`
+	Coverage      string         `yaml:"coverage,omitempty"`
+	Retry         *CIRetry       `yaml:"retry,omitempty"`
+	Timeout       string         `yaml:"timeout,omitempty"`
+	Parallel      *CIParallel    `yaml:"parallel,omitempty"`
+	Interruptible *bool          `yaml:"interruptible,omitempty"`
+	ResourceGroup string         `yaml:"resource_group,omitempty"`
+	Trigger       *CITrigger     `yaml:"trigger,omitempty"`
+
+	// Extra contains all keys of the job that are not part of the model.
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// Hidden reports whether the job is hidden, meaning it is never run and is
+// only used as a template for other jobs.
+func (j *CIJob) Hidden() bool {
+	return strings.HasPrefix(j.Name, ".")
+}
+
+// CIStringList represents a keyword that accepts either a single string or
+// a list of strings. Nested lists, as created by YAML aliases, are
+// flattened. An explicitly empty list is kept when the list is written back.
+type CIStringList []string
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (l *CIStringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+
+	*l = nil
+	if _, ok := v.([]interface{}); ok {
+		*l = CIStringList{}
+	}
+
+	var err error
+	*l, err = flattenCIStrings(v, *l)
+	return err
+}
+
+// IsZero implements the yaml.IsZeroer interface.
+func (l CIStringList) IsZero() bool {
+	return l == nil
+}
+
+func flattenCIStrings(v interface{}, out CIStringList) (CIStringList, error) {
+	switch v := v.(type) {
+	case nil:
+		return out, nil
+	case []interface{}:
+		var err error
+		for _, e := range v {
+			if out, err = flattenCIStrings(e, out); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case map[interface{}]interface{}, yaml.MapSlice:
+		return nil, fmt.Errorf("expected a string or a list of strings, got a mapping")
+	default:
+		return append(out, fmt.Sprint(v)), nil
+	}
+}
+
+// CIVariable represents a variable defined in a .gitlab-ci.yml file.
+type CIVariable struct {
+	Value       string `yaml:"value"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (v *CIVariable) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*v = CIVariable{Value: value}
+		return nil
+	}
+	type ciVariable CIVariable
+	return unmarshal((*ciVariable)(v))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (v CIVariable) MarshalYAML() (interface{}, error) {
+	if v.Description == "" {
+		return v.Value, nil
+	}
+	type ciVariable CIVariable
+	return ciVariable(v), nil
+}
+
+// CIVariables represents the variables of a .gitlab-ci.yml file or job.
+type CIVariables map[string]*CIVariable
+
+// CIInclude represents an entry of the include keyword.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#include
+type CIInclude struct {
+	Local    string       `yaml:"local,omitempty"`
+	Remote   string       `yaml:"remote,omitempty"`
+	Template string       `yaml:"template,omitempty"`
+	Project  string       `yaml:"project,omitempty"`
+	Ref      string       `yaml:"ref,omitempty"`
+	File     CIStringList `yaml:"file,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (i *CIInclude) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+			*i = CIInclude{Remote: s}
+		} else {
+			*i = CIInclude{Local: s}
+		}
+		return nil
+	}
+	type ciInclude CIInclude
+	return unmarshal((*ciInclude)(i))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (i CIInclude) MarshalYAML() (interface{}, error) {
+	if i.Template == "" && i.Project == "" && i.Ref == "" && i.File == nil {
+		switch {
+		case i.Local != "" && i.Remote == "":
+			return i.Local, nil
+		case i.Remote != "" && i.Local == "":
+			return i.Remote, nil
+		}
+	}
+	type ciInclude CIInclude
+	return ciInclude(i), nil
+}
+
+// CIIncludes represents the include keyword, which accepts a single entry
+// or a list of entries.
+type CIIncludes []*CIInclude
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (is *CIIncludes) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single CIInclude
+	if err := unmarshal(&single); err == nil {
+		*is = CIIncludes{&single}
+		return nil
+	}
+	var list []*CIInclude
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*is = list
+	return nil
+}
+
+// CIWorkflow represents the workflow keyword.
+type CIWorkflow struct {
+	Rules []*CIRule `yaml:"rules,omitempty"`
+}
+
+// CIRule represents a single rule of the rules keyword.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#rules
+type CIRule struct {
+	If           string       `yaml:"if,omitempty"`
+	Changes      CIStringList `yaml:"changes,omitempty"`
+	Exists       CIStringList `yaml:"exists,omitempty"`
+	When         string       `yaml:"when,omitempty"`
+	StartIn      string       `yaml:"start_in,omitempty"`
+	AllowFailure *bool        `yaml:"allow_failure,omitempty"`
+	Variables    CIVariables  `yaml:"variables,omitempty"`
+}
+
+// CIOnlyExcept represents the only and except keywords. The short form,
+// a list of refs, is stored in Refs.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#onlyexcept-basic
+type CIOnlyExcept struct {
+	Refs       CIStringList `yaml:"refs,omitempty"`
+	Variables  CIStringList `yaml:"variables,omitempty"`
+	Changes    CIStringList `yaml:"changes,omitempty"`
+	Kubernetes string       `yaml:"kubernetes,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (o *CIOnlyExcept) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var refs CIStringList
+	if err := unmarshal(&refs); err == nil {
+		*o = CIOnlyExcept{Refs: refs}
+		return nil
+	}
+	type ciOnlyExcept CIOnlyExcept
+	return unmarshal((*ciOnlyExcept)(o))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (o CIOnlyExcept) MarshalYAML() (interface{}, error) {
+	if o.Variables == nil && o.Changes == nil && o.Kubernetes == "" {
+		return o.Refs, nil
+	}
+	type ciOnlyExcept CIOnlyExcept
+	return ciOnlyExcept(o), nil
+}
+
+// CINeed represents an entry of the needs keyword.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#needs
+type CINeed struct {
+	Job       string `yaml:"job,omitempty"`
+	Pipeline  string `yaml:"pipeline,omitempty"`
+	Project   string `yaml:"project,omitempty"`
+	Ref       string `yaml:"ref,omitempty"`
+	Artifacts *bool  `yaml:"artifacts,omitempty"`
+	Optional  bool   `yaml:"optional,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (n *CINeed) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var job string
+	if err := unmarshal(&job); err == nil {
+		*n = CINeed{Job: job}
+		return nil
+	}
+	type ciNeed CINeed
+	return unmarshal((*ciNeed)(n))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (n CINeed) MarshalYAML() (interface{}, error) {
+	if n == (CINeed{Job: n.Job}) {
+		return n.Job, nil
+	}
+	type ciNeed CINeed
+	return ciNeed(n), nil
+}
+
+// CINeeds represents the needs keyword. An explicitly empty list, which
+// starts a job immediately, is kept when the list is written back.
+type CINeeds []*CINeed
+
+// IsZero implements the yaml.IsZeroer interface.
+func (n CINeeds) IsZero() bool {
+	return n == nil
+}
+
+// CIImage represents the image keyword and the entries of the services
+// keyword.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#image
+type CIImage struct {
+	Name       string       `yaml:"name"`
+	Entrypoint CIStringList `yaml:"entrypoint,omitempty"`
+	Command    CIStringList `yaml:"command,omitempty"`
+	Alias      string       `yaml:"alias,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (i *CIImage) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*i = CIImage{Name: name}
+		return nil
+	}
+	type ciImage CIImage
+	return unmarshal((*ciImage)(i))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (i CIImage) MarshalYAML() (interface{}, error) {
+	if i.Entrypoint == nil && i.Command == nil && i.Alias == "" {
+		return i.Name, nil
+	}
+	type ciImage CIImage
+	return ciImage(i), nil
+}
+
+// CIArtifacts represents the artifacts keyword.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#artifacts
+type CIArtifacts struct {
+	Name      string                  `yaml:"name,omitempty"`
+	Paths     CIStringList            `yaml:"paths,omitempty"`
+	Exclude   CIStringList            `yaml:"exclude,omitempty"`
+	ExposeAs  string                  `yaml:"expose_as,omitempty"`
+	Untracked *bool                   `yaml:"untracked,omitempty"`
+	When      string                  `yaml:"when,omitempty"`
+	ExpireIn  string                  `yaml:"expire_in,omitempty"`
+	Reports   map[string]CIStringList `yaml:"reports,omitempty"`
+}
+
+// CICache represents the cache keyword.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#cache
+type CICache struct {
+	Key       *CICacheKey  `yaml:"key,omitempty"`
+	Paths     CIStringList `yaml:"paths,omitempty"`
+	Untracked *bool        `yaml:"untracked,omitempty"`
+	Policy    string       `yaml:"policy,omitempty"`
+	When      string       `yaml:"when,omitempty"`
+}
+
+// CICacheKey represents the key of a cache. A plain key is stored in Name,
+// a key computed from files in Files and Prefix.
+type CICacheKey struct {
+	Name   string       `yaml:"-"`
+	Files  CIStringList `yaml:"files,omitempty"`
+	Prefix string       `yaml:"prefix,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (k *CICacheKey) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*k = CICacheKey{Name: name}
+		return nil
+	}
+	type ciCacheKey CICacheKey
+	return unmarshal((*ciCacheKey)(k))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (k CICacheKey) MarshalYAML() (interface{}, error) {
+	if k.Files == nil && k.Prefix == "" {
+		return k.Name, nil
+	}
+	type ciCacheKey CICacheKey
+	return ciCacheKey(k), nil
+}
+
+// CIEnvironment represents the environment keyword.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#environment
+type CIEnvironment struct {
+	Name       string `yaml:"name"`
+	URL        string `yaml:"url,omitempty"`
+	Action     string `yaml:"action,omitempty"`
+	OnStop     string `yaml:"on_stop,omitempty"`
+	AutoStopIn string `yaml:"auto_stop_in,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (e *CIEnvironment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*e = CIEnvironment{Name: name}
+		return nil
+	}
+	type ciEnvironment CIEnvironment
+	return unmarshal((*ciEnvironment)(e))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (e CIEnvironment) MarshalYAML() (interface{}, error) {
+	if e == (CIEnvironment{Name: e.Name}) {
+		return e.Name, nil
+	}
+	type ciEnvironment CIEnvironment
+	return ciEnvironment(e), nil
+}
+
+// CIRetry represents the retry keyword.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#retry
+type CIRetry struct {
+	Max  int          `yaml:"max"`
+	When CIStringList `yaml:"when,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *CIRetry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var max int
+	if err := unmarshal(&max); err == nil {
+		*r = CIRetry{Max: max}
+		return nil
+	}
+	type ciRetry CIRetry
+	return unmarshal((*ciRetry)(r))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (r CIRetry) MarshalYAML() (interface{}, error) {
+	if r.When == nil {
+		return r.Max, nil
+	}
+	type ciRetry CIRetry
+	return ciRetry(r), nil
+}
+
+// CIParallel represents the parallel keyword, either a number of parallel
+// jobs or a matrix of variables.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#parallel
+type CIParallel struct {
+	Count  int                       `yaml:"-"`
+	Matrix []map[string]CIStringList `yaml:"matrix,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (p *CIParallel) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var count int
+	if err := unmarshal(&count); err == nil {
+		*p = CIParallel{Count: count}
+		return nil
+	}
+	type ciParallel CIParallel
+	return unmarshal((*ciParallel)(p))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (p CIParallel) MarshalYAML() (interface{}, error) {
+	if p.Matrix == nil {
+		return p.Count, nil
+	}
+	type ciParallel CIParallel
+	return ciParallel(p), nil
+}
+
+// CITrigger represents the trigger keyword of a bridge job. The short
+// form, a project path, is stored in Project.
+//
+// GitLab docs: https://docs.gitlab.com/ce/ci/yaml/#trigger
+type CITrigger struct {
+	Project  string     `yaml:"project,omitempty"`
+	Branch   string     `yaml:"branch,omitempty"`
+	Strategy string     `yaml:"strategy,omitempty"`
+	Include  CIIncludes `yaml:"include,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (t *CITrigger) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var project string
+	if err := unmarshal(&project); err == nil {
+		*t = CITrigger{Project: project}
+		return nil
+	}
+	type ciTrigger CITrigger
+	return unmarshal((*ciTrigger)(t))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (t CITrigger) MarshalYAML() (interface{}, error) {
+	if t.Branch == "" && t.Strategy == "" && t.Include == nil {
+		return t.Project, nil
+	}
+	type ciTrigger CITrigger
+	return ciTrigger(t), nil
+}
+
+// ParseCIConfig parses the content of a .gitlab-ci.yml file.
+func ParseCIConfig(content []byte) (*CIConfig, error) {
+	c := new(CIConfig)
+	if err := yaml.Unmarshal(content, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Config parses the content of the template.
+func (t *CIYMLTemplate) Config() (*CIConfig, error) {
+	return ParseCIConfig([]byte(t.Content))
+}
+
+// Job returns the job with the given name, or nil if there is no such job.
+func (c *CIConfig) Job(name string) *CIJob {
+	for _, j := range c.Jobs {
+		if j.Name == name {
+			return j
+		}
+	}
+	return nil
+}
+
+// YAML returns the configuration as .gitlab-ci.yml content.
+func (c *CIConfig) YAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *CIConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type ciConfig CIConfig
+	var globals ciConfig
+	if err := unmarshal(&globals); err != nil {
+		return err
+	}
+
+	// The types keyword is a deprecated alias of stages.
+	var legacy struct {
+		Types []string `yaml:"types"`
+	}
+	if err := unmarshal(&legacy); err != nil {
+		return err
+	}
+	if globals.Stages == nil {
+		globals.Stages = legacy.Types
+	}
+
+	// The values are decoded into a map, as merge keys are not resolved
+	// when decoding into a yaml.MapSlice. The slice is only used to keep
+	// the order of the jobs.
+	var values map[string]interface{}
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+	var items yaml.MapSlice
+	if err := unmarshal(&items); err != nil {
+		return err
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		name := fmt.Sprint(item.Key)
+		if _, ok := values[name]; ok && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	var merged []string
+	for name := range values {
+		if !seen[name] {
+			merged = append(merged, name)
+		}
+	}
+	sort.Strings(merged)
+	names = append(names, merged...)
+
+	*c = CIConfig(globals)
+	for _, name := range names {
+		if ciGlobalKeywords[name] {
+			continue
+		}
+		value := values[name]
+
+		job := &CIJob{Name: name}
+		err := fmt.Errorf("not a mapping")
+		if _, ok := value.(map[interface{}]interface{}); ok {
+			err = remarshalYAML(value, job)
+		}
+		if err != nil {
+			// Hidden keys are often only used to define anchors.
+			if !strings.HasPrefix(name, ".") {
+				return fmt.Errorf("invalid job %s: %v", name, err)
+			}
+			if c.Extra == nil {
+				c.Extra = make(map[string]interface{})
+			}
+			c.Extra[name] = value
+			continue
+		}
+		c.Jobs = append(c.Jobs, job)
+	}
+
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (c CIConfig) MarshalYAML() (interface{}, error) {
+	type ciConfig CIConfig
+	var out yaml.MapSlice
+	if err := remarshalYAML(ciConfig(c), &out); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(c.Extra))
+	for k := range c.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out = append(out, yaml.MapItem{Key: k, Value: c.Extra[k]})
+	}
+
+	for _, j := range c.Jobs {
+		out = append(out, yaml.MapItem{Key: j.Name, Value: j})
+	}
+
+	return out, nil
+}
+
+// ResolveExtends merges the configuration of all extended jobs into the
+// jobs extending them, the same way GitLab does: mappings are merged
+// recursively, while lists and scalars of the extending job replace those
+// of the extended job. Afterwards no job has an extends keyword left.
+func (c *CIConfig) ResolveExtends() error {
+	raw := make(map[string]yaml.MapSlice, len(c.Jobs))
+	for _, j := range c.Jobs {
+		var m yaml.MapSlice
+		if err := remarshalYAML(j, &m); err != nil {
+			return err
+		}
+		raw[j.Name] = m
+	}
+
+	resolved := make(map[string]yaml.MapSlice, len(c.Jobs))
+	visiting := make(map[string]bool)
+
+	var resolve func(name string) (yaml.MapSlice, error)
+	resolve = func(name string) (yaml.MapSlice, error) {
+		if m, ok := resolved[name]; ok {
+			return m, nil
+		}
+		if visiting[name] {
+			return nil, fmt.Errorf("circular extends of job %s", name)
+		}
+		visiting[name] = true
+
+		job := c.Job(name)
+		var merged yaml.MapSlice
+		for _, parent := range job.Extends {
+			if c.Job(parent) == nil {
+				return nil, fmt.Errorf("job %s extends unknown job %s", name, parent)
+			}
+			m, err := resolve(parent)
+			if err != nil {
+				return nil, err
+			}
+			merged = mergeYAMLMaps(merged, m)
+		}
+
+		var own yaml.MapSlice
+		for _, item := range raw[name] {
+			if item.Key != "extends" {
+				own = append(own, item)
+			}
+		}
+		merged = mergeYAMLMaps(merged, own)
+
+		resolved[name] = merged
+		return merged, nil
+	}
+
+	jobs := make([]*CIJob, 0, len(c.Jobs))
+	for _, j := range c.Jobs {
+		m, err := resolve(j.Name)
+		if err != nil {
+			return err
+		}
+		job := &CIJob{Name: j.Name}
+		if err := remarshalYAML(m, job); err != nil {
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+	c.Jobs = jobs
+
+	return nil
+}
+
+// mergeYAMLMaps returns a deep merge of base and override, without
+// modifying either of them.
+func mergeYAMLMaps(base, override yaml.MapSlice) yaml.MapSlice {
+	out := make(yaml.MapSlice, len(base), len(base)+len(override))
+	copy(out, base)
+
+	for _, item := range override {
+		found := false
+		for i := range out {
+			if out[i].Key != item.Key {
+				continue
+			}
+			bm, ok1 := out[i].Value.(yaml.MapSlice)
+			om, ok2 := item.Value.(yaml.MapSlice)
+			if ok1 && ok2 {
+				out[i].Value = mergeYAMLMaps(bm, om)
+			} else {
+				out[i].Value = item.Value
+			}
+			found = true
+			break
+		}
+		if !found {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+// remarshalYAML converts in to out by encoding and decoding it.
+func remarshalYAML(in, out interface{}) error {
+	b, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(b, out)
+}
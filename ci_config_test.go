@@ -0,0 +1,171 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const testCIConfig = `
+include:
+  - local: /templates/common.yml
+  - template: Auto-DevOps.gitlab-ci.yml
+stages: [build, test, deploy]
+variables:
+  GO_VERSION: "1.12"
+  DEPLOY_ENV:
+    value: staging
+    description: The environment to deploy to.
+.go: &go
+  image: golang:1.12
+  tags: [docker]
+.test:
+  <<: *go
+  stage: test
+  variables:
+    CGO_ENABLED: "0"
+  cache:
+    key: go-mod
+    paths: [.cache]
+build:
+  <<: *go
+  stage: build
+  script: go build ./...
+  artifacts:
+    paths: [bin/]
+    expire_in: 1 week
+    reports:
+      junit: report.xml
+unit:
+  extends: .test
+  variables:
+    GOFLAGS: -mod=vendor
+  script:
+    - go test ./...
+  needs: [build]
+  only:
+    refs: [master]
+    changes: ["**/*.go"]
+deploy:
+  stage: deploy
+  script: [./deploy.sh]
+  needs: []
+  rules:
+    - if: '$CI_COMMIT_BRANCH == "master"'
+      when: manual
+  environment: staging
+  release:
+    tag_name: v1.0.0
+`
+
+func TestParseCIConfig(t *testing.T) {
+	c, err := ParseCIConfig([]byte(testCIConfig))
+	if err != nil {
+		t.Fatalf("ParseCIConfig returned error: %v", err)
+	}
+
+	want := CIIncludes{{Local: "/templates/common.yml"}, {Template: "Auto-DevOps.gitlab-ci.yml"}}
+	if !reflect.DeepEqual(want, c.Include) {
+		t.Errorf("ParseCIConfig returned includes %+v, want %+v", c.Include, want)
+	}
+	if c.Variables["DEPLOY_ENV"].Value != "staging" || c.Variables["GO_VERSION"].Value != "1.12" {
+		t.Errorf("ParseCIConfig returned variables %+v", c.Variables)
+	}
+
+	var names []string
+	for _, j := range c.Jobs {
+		names = append(names, j.Name)
+	}
+	if !reflect.DeepEqual([]string{".go", ".test", "build", "unit", "deploy"}, names) {
+		t.Errorf("ParseCIConfig returned jobs %v", names)
+	}
+
+	build := c.Job("build")
+	if build.Image.Name != "golang:1.12" || !reflect.DeepEqual(CIStringList{"go build ./..."}, build.Script) {
+		t.Errorf("ParseCIConfig returned build job %+v", build)
+	}
+	if !reflect.DeepEqual(CIStringList{"report.xml"}, build.Artifacts.Reports["junit"]) {
+		t.Errorf("ParseCIConfig returned artifacts %+v", build.Artifacts)
+	}
+
+	unit := c.Job("unit")
+	if unit.Only.Refs[0] != "master" || unit.Needs[0].Job != "build" {
+		t.Errorf("ParseCIConfig returned unit job %+v", unit)
+	}
+
+	deploy := c.Job("deploy")
+	if deploy.Needs == nil || len(deploy.Needs) != 0 {
+		t.Errorf("ParseCIConfig returned needs %v, want an empty list", deploy.Needs)
+	}
+	if deploy.Environment.Name != "staging" || deploy.Rules[0].When != "manual" {
+		t.Errorf("ParseCIConfig returned deploy job %+v", deploy)
+	}
+	if _, ok := deploy.Extra["release"]; !ok {
+		t.Errorf("ParseCIConfig dropped unknown key release: %+v", deploy.Extra)
+	}
+
+	// Writing the configuration back and parsing it again must not change it.
+	out, err := c.YAML()
+	if err != nil {
+		t.Fatalf("CIConfig.YAML returned error: %v", err)
+	}
+	again, err := ParseCIConfig(out)
+	if err != nil {
+		t.Fatalf("ParseCIConfig returned error: %v\n%s", err, out)
+	}
+	if !reflect.DeepEqual(c, again) {
+		t.Errorf("round trip changed the configuration:\n%s", out)
+	}
+}
+
+func TestCIConfigResolveExtends(t *testing.T) {
+	c, err := ParseCIConfig([]byte(testCIConfig))
+	if err != nil {
+		t.Fatalf("ParseCIConfig returned error: %v", err)
+	}
+
+	if err := c.ResolveExtends(); err != nil {
+		t.Fatalf("CIConfig.ResolveExtends returned error: %v", err)
+	}
+
+	unit := c.Job("unit")
+	if unit.Extends != nil || unit.Stage != "test" || unit.Image.Name != "golang:1.12" {
+		t.Errorf("CIConfig.ResolveExtends returned %+v", unit)
+	}
+	if unit.Variables["CGO_ENABLED"].Value != "0" || unit.Variables["GOFLAGS"].Value != "-mod=vendor" {
+		t.Errorf("CIConfig.ResolveExtends did not merge variables: %+v", unit.Variables)
+	}
+	if unit.Cache.Key.Name != "go-mod" {
+		t.Errorf("CIConfig.ResolveExtends returned cache %+v", unit.Cache)
+	}
+
+	c.Job("unit").Extends = CIStringList{"deploy"}
+	c.Job("deploy").Extends = CIStringList{"unit"}
+	if err := c.ResolveExtends(); err == nil {
+		t.Error("CIConfig.ResolveExtends returned no error for circular extends")
+	}
+}
+
+func TestCIYMLTemplateConfig(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/templates/gitlab_ci_ymls/Go", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"name":"Go","content":"image: golang:latest\nstages:\n  - test\nformat:\n  stage: test\n  script:\n    - go fmt ./...\n"}`)
+	})
+
+	template, _, err := client.CIYMLTemplate.GetTemplate("Go")
+	if err != nil {
+		t.Fatalf("CIYMLTemplate.GetTemplate returned error: %v", err)
+	}
+
+	c, err := template.Config()
+	if err != nil {
+		t.Fatalf("CIYMLTemplate.Config returned error: %v", err)
+	}
+	if c.Image.Name != "golang:latest" || len(c.Jobs) != 1 || c.Jobs[0].Name != "format" {
+		t.Errorf("CIYMLTemplate.Config returned %+v", c)
+	}
+}
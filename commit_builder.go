@@ -0,0 +1,365 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// CommitBuilder builds a commit that makes a directory of a branch match a
+// set of local files. Only the files that differ from the remote tree are
+// sent, which is determined by comparing git blob SHAs.
+type CommitBuilder struct {
+	client *Client
+	pid    interface{}
+	branch string
+
+	// StartBranch is the branch the files are compared to, and the new
+	// branch is created from, if Branch doesn't exist yet.
+	StartBranch string
+
+	// Dir is the directory of the repository that contains the files.
+	// Defaults to the root of the repository.
+	Dir string
+
+	// Delete deletes remote files in Dir that are not in the file set.
+	// Deleted files with the same content as a new file are moved
+	// instead.
+	Delete bool
+
+	// MaxActions and MaxContentSize limit the number of actions and the
+	// size of the content of a single commit. If the changes exceed these
+	// limits, they are split over multiple commits. Zero means no limit.
+	MaxActions     int
+	MaxContentSize int
+
+	files map[string]*commitBuilderFile
+}
+
+type commitBuilderFile struct {
+	content    []byte
+	executable bool
+}
+
+// NewCommitBuilder returns a new CommitBuilder for the given branch.
+func NewCommitBuilder(client *Client, pid interface{}, branch string) *CommitBuilder {
+	return &CommitBuilder{
+		client: client,
+		pid:    pid,
+		branch: branch,
+		files:  make(map[string]*commitBuilderFile),
+	}
+}
+
+// AddFile adds a file to the file set. The name is relative to Dir.
+func (b *CommitBuilder) AddFile(name string, content []byte, executable bool) error {
+	name = strings.TrimPrefix(path.Clean(name), "/")
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	b.files[name] = &commitBuilderFile{content: content, executable: executable}
+	return nil
+}
+
+// AddDir adds all regular files in a local directory to the file set. The
+// executable bit is taken from the file mode. Directories named .git are
+// skipped.
+func (b *CommitBuilder) AddDir(dir string) error {
+	return filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		return b.AddFile(filepath.ToSlash(rel), content, info.Mode()&0111 != 0)
+	})
+}
+
+// Actions compares the file set to the remote tree and returns the actions
+// needed to make them match, ordered by file path.
+func (b *CommitBuilder) Actions(options ...OptionFunc) ([]*CommitAction, error) {
+	actions, _, err := b.actions(options)
+	return actions, err
+}
+
+// actions returns the actions and whether the branch already exists.
+func (b *CommitBuilder) actions(options []OptionFunc) ([]*CommitAction, bool, error) {
+	remote, exists, err := b.remoteFiles(options)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var names []string
+	for name := range b.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Files with the same content as a deleted file are moved.
+	var deleted []string
+	if b.Delete {
+		for p := range remote {
+			if _, ok := b.files[b.relative(p)]; !ok {
+				deleted = append(deleted, p)
+			}
+		}
+		sort.Strings(deleted)
+	}
+	moves := make(map[string]string)
+	moved := make(map[string]bool)
+	for _, name := range names {
+		p := b.remotePath(name)
+		if _, ok := remote[p]; ok {
+			continue
+		}
+		sha := gitBlobSHA(b.files[name].content)
+		for _, d := range deleted {
+			if !moved[d] && remote[d].ID == sha {
+				moves[p] = d
+				moved[d] = true
+				break
+			}
+		}
+	}
+
+	var actions []*CommitAction
+	for _, name := range names {
+		f := b.files[name]
+		p := b.remotePath(name)
+		n, exists := remote[p]
+
+		switch {
+		case moves[p] != "":
+			actions = append(actions, &CommitAction{Action: FileMove, FilePath: p, PreviousPath: moves[p]})
+			if f.executable != (remote[moves[p]].Mode == "100755") {
+				actions = append(actions, chmodAction(p, f.executable))
+			}
+		case !exists:
+			actions = append(actions, contentAction(FileCreate, p, f.content))
+			if f.executable {
+				actions = append(actions, chmodAction(p, true))
+			}
+		default:
+			if n.ID != gitBlobSHA(f.content) {
+				actions = append(actions, contentAction(FileUpdate, p, f.content))
+			}
+			if f.executable != (n.Mode == "100755") {
+				actions = append(actions, chmodAction(p, f.executable))
+			}
+		}
+	}
+
+	for _, d := range deleted {
+		if !moved[d] {
+			actions = append(actions, &CommitAction{Action: FileDelete, FilePath: d})
+		}
+	}
+
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].FilePath < actions[j].FilePath })
+
+	return actions, exists, nil
+}
+
+// Commit creates the commits that make the remote tree match the file set.
+// Branch, StartBranch and Actions of opt are set by the builder; StartBranch
+// is only used if the branch doesn't exist yet. If the
+// changes are split over multiple commits, the commit message of every
+// commit gets a "(part i/n)" suffix. No commit is created if there are no
+// changes.
+func (b *CommitBuilder) Commit(opt *CreateCommitOptions, options ...OptionFunc) ([]*Commit, error) {
+	if opt == nil {
+		opt = &CreateCommitOptions{}
+	}
+
+	actions, exists, err := b.actions(options)
+	if err != nil {
+		return nil, err
+	}
+	batches := b.split(actions)
+
+	var commits []*Commit
+	for i, batch := range batches {
+		o := *opt
+		o.Branch = String(b.branch)
+		o.Actions = batch
+		o.StartBranch = nil
+		if i == 0 && !exists && b.StartBranch != "" {
+			o.StartBranch = String(b.StartBranch)
+		}
+		if len(batches) > 1 && opt.CommitMessage != nil {
+			o.CommitMessage = String(fmt.Sprintf("%s (part %d/%d)", *opt.CommitMessage, i+1, len(batches)))
+		}
+
+		c, _, err := b.client.Commits.CreateCommit(b.pid, &o, options...)
+		if err != nil {
+			return commits, err
+		}
+		commits = append(commits, c)
+	}
+
+	return commits, nil
+}
+
+// split splits the actions into batches within the limits. A chmod action
+// stays in the same batch as the action before it on the same file.
+func (b *CommitBuilder) split(actions []*CommitAction) [][]*CommitAction {
+	var batches [][]*CommitAction
+	var batch []*CommitAction
+	size := 0
+
+	for i, a := range actions {
+		together := i > 0 && a.Action == FileChmod && actions[i-1].FilePath == a.FilePath
+		full := (b.MaxActions > 0 && len(batch) >= b.MaxActions) ||
+			(b.MaxContentSize > 0 && size > 0 && size+len(a.Content) > b.MaxContentSize)
+
+		if full && !together {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, a)
+		size += len(a.Content)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// remoteFiles returns the blobs in Dir of the branch, or of StartBranch if
+// the branch doesn't exist. Symbolic links and submodules are ignored. It
+// also reports whether the branch exists.
+func (b *CommitBuilder) remoteFiles(options []OptionFunc) (map[string]*TreeNode, bool, error) {
+	ref := b.branch
+	exists := true
+	_, _, err := b.client.Branches.GetBranch(b.pid, b.branch, options...)
+	if isNotFound(err) {
+		exists = false
+		ref, err = b.StartBranch, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if ref == "" {
+		return map[string]*TreeNode{}, false, nil
+	}
+
+	opt := &ListTreeOptions{
+		ListOptions: ListOptions{Page: 1, PerPage: 100},
+		Ref:         String(ref),
+		Recursive:   Bool(true),
+	}
+	if dir := b.remotePath(""); dir != "" {
+		opt.Path = String(strings.TrimSuffix(dir, "/"))
+	}
+
+	files := make(map[string]*TreeNode)
+	for {
+		nodes, resp, err := b.client.Repositories.ListTree(b.pid, opt, options...)
+		if isNotFound(err) {
+			// Dir doesn't exist yet.
+			return files, exists, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		for _, n := range nodes {
+			if n.Type == "blob" && n.Mode != "120000" {
+				files[n.Path] = n
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return files, exists, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// remotePath returns the path in the repository of a file in the set.
+func (b *CommitBuilder) remotePath(name string) string {
+	dir := strings.Trim(b.Dir, "/")
+	if dir == "" || dir == "." {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// relative returns the name in the set of a path in the repository.
+func (b *CommitBuilder) relative(p string) string {
+	return strings.TrimPrefix(p, b.remotePath(""))
+}
+
+func contentAction(action FileAction, p string, content []byte) *CommitAction {
+	a := &CommitAction{Action: action, FilePath: p}
+	a.Encoding, a.Content = encodeContent(content)
+	return a
+}
+
+// encodeContent returns the encoding and the encoded content to send to the
+// API. Text is sent as is, binary content is base64 encoded.
+func encodeContent(content []byte) (string, string) {
+	if utf8.Valid(content) && bytes.IndexByte(content, 0) < 0 {
+		return "", string(content)
+	}
+	return "base64", base64.StdEncoding.EncodeToString(content)
+}
+
+func chmodAction(p string, executable bool) *CommitAction {
+	return &CommitAction{Action: FileChmod, FilePath: p, ExecuteFilemode: Bool(executable)}
+}
+
+// gitBlobSHA returns the SHA git uses for a blob with the given content.
+func gitBlobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*ErrorResponse)
+	return ok && e.Response != nil && e.Response.StatusCode == http.StatusNotFound
+}
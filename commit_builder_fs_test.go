@@ -0,0 +1,27 @@
+//go:build go1.16
+// +build go1.16
+
+package gitlab
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestCommitBuilderAddFS(t *testing.T) {
+	files := fstest.MapFS{
+		"src/a.txt":      {Data: []byte("a")},
+		"src/run.sh":     {Data: []byte("#!/bin/sh"), Mode: 0755},
+		"src/.git/HEAD":  {Data: []byte("ref: refs/heads/master")},
+		"other/skip.txt": {Data: []byte("skip")},
+	}
+
+	b := NewCommitBuilder(nil, 1, "master")
+	if err := b.AddFS(files, "src"); err != nil {
+		t.Fatalf("CommitBuilder.AddFS returned error: %v", err)
+	}
+
+	if len(b.files) != 2 || string(b.files["a.txt"].content) != "a" || !b.files["run.sh"].executable {
+		t.Errorf("CommitBuilder.AddFS added %v", b.files)
+	}
+}
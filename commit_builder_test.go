@@ -0,0 +1,151 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommitBuilder(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/branches/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if !strings.HasSuffix(r.URL.Path, "/master") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"404 Branch Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"master"}`)
+	})
+
+	mux.HandleFunc("/api/v4/projects/1/repository/tree", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		q := r.URL.Query()
+		if q.Get("ref") != "master" || q.Get("recursive") != "true" {
+			t.Errorf("Request query is %v", q)
+		}
+		nodes := []string{
+			fmt.Sprintf(`{"id":"%s","name":"a.txt","type":"blob","path":"config/a.txt","mode":"100644"}`, gitBlobSHA([]byte("a"))),
+			fmt.Sprintf(`{"id":"%s","name":"b.txt","type":"blob","path":"config/b.txt","mode":"100644"}`, gitBlobSHA([]byte("b"))),
+			fmt.Sprintf(`{"id":"%s","name":"old.txt","type":"blob","path":"config/old.txt","mode":"100644"}`, gitBlobSHA([]byte("moved"))),
+			fmt.Sprintf(`{"id":"%s","name":"gone.txt","type":"blob","path":"config/gone.txt","mode":"100644"}`, gitBlobSHA([]byte("gone"))),
+			fmt.Sprintf(`{"id":"%s","name":"run.sh","type":"blob","path":"config/run.sh","mode":"100644"}`, gitBlobSHA([]byte("#!/bin/sh"))),
+			fmt.Sprintf(`{"id":"%s","name":"x.txt","type":"blob","path":"other/x.txt","mode":"100644"}`, gitBlobSHA([]byte("x"))),
+		}
+		var matched []string
+		for _, n := range nodes {
+			if p := q.Get("path"); p == "" || strings.Contains(n, `"path":"`+p+"/") {
+				matched = append(matched, n)
+			}
+		}
+		if len(matched) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"404 Tree Not Found"}`)
+			return
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(matched, ","))
+	})
+
+	var commits []*CreateCommitOptions
+	mux.HandleFunc("/api/v4/projects/1/repository/commits", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		opt := new(CreateCommitOptions)
+		json.NewDecoder(r.Body).Decode(opt)
+		commits = append(commits, opt)
+		fmt.Fprintf(w, `{"id":"c%d"}`, len(commits))
+	})
+
+	dir, err := ioutil.TempDir("", "commit-builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string][]byte{
+		"a.txt":     []byte("a"),
+		"b.txt":     []byte("b2"),
+		"new.txt":   []byte("moved"),
+		"run.sh":    []byte("#!/bin/sh"),
+		"image.bin": {0x89, 'P', 'N', 'G', 0},
+		".git/HEAD": []byte("ref: refs/heads/master"),
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Chmod(filepath.Join(dir, "run.sh"), 0755)
+
+	b := NewCommitBuilder(client, 1, "master")
+	b.Dir = "config"
+	b.Delete = true
+	if err := b.AddDir(dir); err != nil {
+		t.Fatalf("CommitBuilder.AddDir returned error: %v", err)
+	}
+
+	actions, err := b.Actions()
+	if err != nil {
+		t.Fatalf("CommitBuilder.Actions returned error: %v", err)
+	}
+	want := []*CommitAction{
+		{Action: FileUpdate, FilePath: "config/b.txt", Content: "b2"},
+		{Action: FileDelete, FilePath: "config/gone.txt"},
+		{Action: FileCreate, FilePath: "config/image.bin", Content: "iVBORwA=", Encoding: "base64"},
+		{Action: FileMove, FilePath: "config/new.txt", PreviousPath: "config/old.txt"},
+		{Action: FileChmod, FilePath: "config/run.sh", ExecuteFilemode: Bool(true)},
+	}
+	if !reflect.DeepEqual(actions, want) {
+		for _, a := range actions {
+			t.Logf("%+v", a)
+		}
+		t.Fatalf("CommitBuilder.Actions returned unexpected actions")
+	}
+
+	b.MaxActions = 3
+	result, err := b.Commit(&CreateCommitOptions{CommitMessage: String("Sync config")})
+	if err != nil {
+		t.Fatalf("CommitBuilder.Commit returned error: %v", err)
+	}
+	if len(result) != 2 || len(commits) != 2 {
+		t.Fatalf("CommitBuilder.Commit created %d commits, want 2", len(commits))
+	}
+	if *commits[0].CommitMessage != "Sync config (part 1/2)" || len(commits[0].Actions) != 3 || commits[0].StartBranch != nil {
+		t.Errorf("CommitBuilder.Commit created %+v", commits[0])
+	}
+
+	// A new branch is compared to and created from the start branch.
+	commits = nil
+	b = NewCommitBuilder(client, 1, "feature")
+	b.StartBranch = "master"
+	b.AddFile("other/x.txt", []byte("y"), false)
+	if _, err := b.Commit(&CreateCommitOptions{CommitMessage: String("Update x")}); err != nil {
+		t.Fatalf("CommitBuilder.Commit returned error: %v", err)
+	}
+	if len(commits) != 1 || *commits[0].StartBranch != "master" || commits[0].Actions[0].Action != FileUpdate {
+		t.Errorf("CommitBuilder.Commit created %+v", commits)
+	}
+}
+
+func TestCommitBuilderAddFile(t *testing.T) {
+	b := NewCommitBuilder(nil, 1, "master")
+	for _, name := range []string{"", ".", "..", "../x", "a/../../x"} {
+		if err := b.AddFile(name, nil, false); err == nil {
+			t.Errorf("CommitBuilder.AddFile(%q) returned no error", name)
+		}
+	}
+	if err := b.AddFile("/a/./b/../c.txt", nil, false); err != nil {
+		t.Errorf("CommitBuilder.AddFile returned error: %v", err)
+	}
+	if _, ok := b.files["a/c.txt"]; !ok {
+		t.Errorf("CommitBuilder.AddFile added %v", b.files)
+	}
+}
@@ -111,15 +111,18 @@ const (
 	FileDelete FileAction = "delete"
 	FileMove   FileAction = "move"
 	FileUpdate FileAction = "update"
+	FileChmod  FileAction = "chmod"
 )
 
 // CommitAction represents a single file action within a commit.
 type CommitAction struct {
-	Action       FileAction `url:"action" bson:"action" json:"action"`
-	FilePath     string     `url:"file_path" bson:"file_path" json:"file_path"`
-	PreviousPath string     `url:"previous_path,omitempty" bson:"previous_path,omitempty" json:"previous_path,omitempty"`
-	Content      string     `url:"content,omitempty" bson:"content,omitempty" json:"content,omitempty"`
-	Encoding     string     `url:"encoding,omitempty" bson:"encoding,omitempty" json:"encoding,omitempty"`
+	Action          FileAction `url:"action" bson:"action" json:"action"`
+	FilePath        string     `url:"file_path" bson:"file_path" json:"file_path"`
+	PreviousPath    string     `url:"previous_path,omitempty" bson:"previous_path,omitempty" json:"previous_path,omitempty"`
+	Content         string     `url:"content,omitempty" bson:"content,omitempty" json:"content,omitempty"`
+	Encoding        string     `url:"encoding,omitempty" bson:"encoding,omitempty" json:"encoding,omitempty"`
+	ExecuteFilemode *bool      `url:"execute_filemode,omitempty" bson:"execute_filemode,omitempty" json:"execute_filemode,omitempty"`
+	LastCommitID    string     `url:"last_commit_id,omitempty" bson:"last_commit_id,omitempty" json:"last_commit_id,omitempty"`
 }
 
 // CommitRef represents the reference of branches/tags in a commit.
@@ -0,0 +1,344 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// diffHunkHeaderRegexp matches hunk headers like "@@ -1,3 +1,4 @@ func main()".
+var diffHunkHeaderRegexp = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// DiffLineTypeValue represents the type of a line in a diff.
+type DiffLineTypeValue string
+
+// List of available diff line types.
+const (
+	DiffContextLine DiffLineTypeValue = "context"
+	DiffAddedLine   DiffLineTypeValue = "added"
+	DiffRemovedLine DiffLineTypeValue = "removed"
+)
+
+// DiffLine represents a single line of a diff hunk. OldLine is zero for
+// added lines and NewLine is zero for removed lines.
+type DiffLine struct {
+	Type    DiffLineTypeValue
+	Content string
+	OldLine int
+	NewLine int
+
+	// NoNewline reports whether the line is the last line of the file
+	// and isn't terminated by a newline.
+	NoNewline bool
+}
+
+// DiffHunk represents a hunk of a unified diff.
+type DiffHunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+
+	// Section is the text after the hunk header, usually the function the
+	// hunk is part of.
+	Section string
+
+	Lines []*DiffLine
+}
+
+// ParsedDiff represents the diff of a single file.
+type ParsedDiff struct {
+	OldPath     string
+	NewPath     string
+	OldMode     string
+	NewMode     string
+	NewFile     bool
+	RenamedFile bool
+	DeletedFile bool
+
+	// Binary reports whether the file is binary. Binary diffs have no
+	// hunks.
+	Binary bool
+
+	Hunks []*DiffHunk
+}
+
+// ModeChanged reports whether the file mode of an existing file changed.
+func (d *ParsedDiff) ModeChanged() bool {
+	return !d.NewFile && !d.DeletedFile && d.OldMode != "" && d.NewMode != "" && d.OldMode != d.NewMode
+}
+
+// Parse parses the unified diff of the file.
+func (d Diff) Parse() (*ParsedDiff, error) {
+	p := &ParsedDiff{
+		OldPath:     d.OldPath,
+		NewPath:     d.NewPath,
+		OldMode:     d.AMode,
+		NewMode:     d.BMode,
+		NewFile:     d.NewFile,
+		RenamedFile: d.RenamedFile,
+		DeletedFile: d.DeletedFile,
+	}
+
+	var err error
+	p.Hunks, p.Binary, err = ParseDiffHunks(d.Diff)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", d.NewPath, err)
+	}
+
+	return p, nil
+}
+
+// ParseDiffHunks parses the hunks of a unified diff of a single file, as
+// returned by the API. Git headers before the first hunk are skipped. It
+// also reports whether the diff is of a binary file.
+func ParseDiffHunks(diff string) ([]*DiffHunk, bool, error) {
+	var hunks []*DiffHunk
+	var h *DiffHunk
+	var oldLine, newLine, oldLeft, newLeft int
+
+	lines := strings.Split(diff, "\n")
+	// A trailing newline doesn't start another line.
+	if n := len(lines); n > 0 && lines[n-1] == "" {
+		lines = lines[:n-1]
+	}
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, "@@ ") {
+			if h != nil && (oldLeft > 0 || newLeft > 0) {
+				return nil, false, fmt.Errorf("line %d: hunk is shorter than its header", i+1)
+			}
+
+			m := diffHunkHeaderRegexp.FindStringSubmatch(line)
+			if m == nil {
+				return nil, false, fmt.Errorf("line %d: invalid hunk header %q", i+1, line)
+			}
+			h = &DiffHunk{
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+				Section:  m[5],
+			}
+			hunks = append(hunks, h)
+			oldLine, newLine = h.OldStart, h.NewStart
+			oldLeft, newLeft = h.OldLines, h.NewLines
+			continue
+		}
+
+		if h == nil {
+			if strings.HasPrefix(line, "Binary files ") || strings.HasPrefix(line, "GIT binary patch") {
+				return nil, true, nil
+			}
+			// Skip git headers like "diff --git", "index" and "---".
+			continue
+		}
+
+		if strings.HasPrefix(line, `\`) {
+			// "\ No newline at end of file" applies to the previous line.
+			if n := len(h.Lines); n > 0 {
+				h.Lines[n-1].NoNewline = true
+			}
+			continue
+		}
+
+		l := &DiffLine{}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			l.Type, l.NewLine = DiffAddedLine, newLine
+			newLine++
+			newLeft--
+		case strings.HasPrefix(line, "-"):
+			l.Type, l.OldLine = DiffRemovedLine, oldLine
+			oldLine++
+			oldLeft--
+		case strings.HasPrefix(line, " ") || line == "":
+			// Some tools strip the space of empty context lines.
+			l.Type, l.OldLine, l.NewLine = DiffContextLine, oldLine, newLine
+			oldLine++
+			newLine++
+			oldLeft--
+			newLeft--
+		default:
+			return nil, false, fmt.Errorf("line %d: unexpected diff line %q", i+1, line)
+		}
+		if line != "" {
+			l.Content = line[1:]
+		}
+
+		if oldLeft < 0 || newLeft < 0 {
+			return nil, false, fmt.Errorf("line %d: hunk is longer than its header", i+1)
+		}
+		h.Lines = append(h.Lines, l)
+	}
+
+	if h != nil && (oldLeft > 0 || newLeft > 0) {
+		return nil, false, fmt.Errorf("last hunk is shorter than its header")
+	}
+
+	return hunks, false, nil
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// oldRange and newRange return the lines covered by the hunk, excluding
+// end. If a hunk has no lines on one side, its start is the line before it.
+func (h *DiffHunk) oldRange() (int, int) {
+	start := h.OldStart
+	if h.OldLines == 0 {
+		start++
+	}
+	return start, start + h.OldLines
+}
+
+func (h *DiffHunk) newRange() (int, int) {
+	start := h.NewStart
+	if h.NewLines == 0 {
+		start++
+	}
+	return start, start + h.NewLines
+}
+
+// offset returns the difference between new and old line numbers after the
+// hunk.
+func (h *DiffHunk) offset() int {
+	_, oldEnd := h.oldRange()
+	_, newEnd := h.newRange()
+	return newEnd - oldEnd
+}
+
+// Lines returns all lines of all hunks.
+func (d *ParsedDiff) Lines() []*DiffLine {
+	var lines []*DiffLine
+	for _, h := range d.Hunks {
+		lines = append(lines, h.Lines...)
+	}
+	return lines
+}
+
+// Position returns the position of a note on the given line of the diff.
+// Added lines only have a new line, removed lines only an old line and
+// context lines have both.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/discussions.html#create-new-merge-request-thread
+func (d *ParsedDiff) Position(line *DiffLine, baseSHA, startSHA, headSHA string) *NotePosition {
+	return &NotePosition{
+		BaseSHA:      baseSHA,
+		StartSHA:     startSHA,
+		HeadSHA:      headSHA,
+		PositionType: "text",
+		OldPath:      d.OldPath,
+		NewPath:      d.NewPath,
+		OldLine:      line.OldLine,
+		NewLine:      line.NewLine,
+	}
+}
+
+// NewLine returns the line with the given line number in the new file. Lines
+// that are not part of a hunk are unchanged; for those a context line is
+// returned with the old line number derived from the preceding hunks. Nil
+// is returned for binary and deleted files, or if the line doesn't exist.
+func (d *ParsedDiff) NewLine(n int) *DiffLine {
+	if d.Binary || d.DeletedFile || n <= 0 {
+		return nil
+	}
+
+	offset := 0
+	for _, h := range d.Hunks {
+		start, end := h.newRange()
+		if n < start {
+			break
+		}
+		if n < end {
+			for _, l := range h.Lines {
+				if l.NewLine == n {
+					return l
+				}
+			}
+			return nil
+		}
+		offset = h.offset()
+	}
+	if d.NewFile {
+		return nil
+	}
+
+	return &DiffLine{Type: DiffContextLine, OldLine: n - offset, NewLine: n}
+}
+
+// OldLine returns the line with the given line number in the old file, like
+// NewLine does for the new file.
+func (d *ParsedDiff) OldLine(n int) *DiffLine {
+	if d.Binary || d.NewFile || n <= 0 {
+		return nil
+	}
+
+	offset := 0
+	for _, h := range d.Hunks {
+		start, end := h.oldRange()
+		if n < start {
+			break
+		}
+		if n < end {
+			for _, l := range h.Lines {
+				if l.OldLine == n {
+					return l
+				}
+			}
+			return nil
+		}
+		offset = h.offset()
+	}
+	if d.DeletedFile {
+		return nil
+	}
+
+	return &DiffLine{Type: DiffContextLine, OldLine: n, NewLine: n + offset}
+}
+
+// ParseChanges parses the changes of a merge request, as returned by
+// GetMergeRequestChanges.
+func (m *MergeRequest) ParseChanges() ([]*ParsedDiff, error) {
+	var diffs []*ParsedDiff
+	for _, c := range m.Changes {
+		d, err := c.Parse()
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, nil
+}
+
+// NotePosition returns the position of a note on a line of one of the
+// changes of the merge request, using the diff refs of the merge request.
+func (m *MergeRequest) NotePosition(d *ParsedDiff, line *DiffLine) *NotePosition {
+	return d.Position(line, m.DiffRefs.BaseSha, m.DiffRefs.StartSha, m.DiffRefs.HeadSha)
+}
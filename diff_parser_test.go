@@ -0,0 +1,121 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const testUnifiedDiff = `--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@ package main
+ import "fmt"
+-
++import "os"
++
+ func main() {
+@@ -10,3 +11,2 @@ func main() {
+ 	fmt.Println("a")
+-	fmt.Println("b")
+ }
+\ No newline at end of file
+`
+
+func TestParseDiffHunks(t *testing.T) {
+	hunks, binary, err := ParseDiffHunks(testUnifiedDiff)
+	if err != nil {
+		t.Fatalf("ParseDiffHunks returned error: %v", err)
+	}
+	if binary || len(hunks) != 2 {
+		t.Fatalf("ParseDiffHunks returned %d hunks, binary %v", len(hunks), binary)
+	}
+
+	want := []*DiffLine{
+		{Type: DiffContextLine, Content: `import "fmt"`, OldLine: 1, NewLine: 1},
+		{Type: DiffRemovedLine, Content: "", OldLine: 2},
+		{Type: DiffAddedLine, Content: `import "os"`, NewLine: 2},
+		{Type: DiffAddedLine, Content: "", NewLine: 3},
+		{Type: DiffContextLine, Content: "func main() {", OldLine: 3, NewLine: 4},
+	}
+	if !reflect.DeepEqual(hunks[0].Lines, want) {
+		t.Errorf("ParseDiffHunks returned %+v, want %+v", hunks[0].Lines, want)
+	}
+	if hunks[0].Section != "package main" || hunks[1].OldStart != 10 || hunks[1].NewLines != 2 {
+		t.Errorf("ParseDiffHunks returned hunk headers %+v and %+v", hunks[0], hunks[1])
+	}
+	if last := hunks[1].Lines[2]; !last.NoNewline || last.OldLine != 12 || last.NewLine != 12 {
+		t.Errorf("ParseDiffHunks returned last line %+v", last)
+	}
+
+	if _, binary, _ := ParseDiffHunks("Binary files a/logo.png and b/logo.png differ\n"); !binary {
+		t.Errorf("ParseDiffHunks didn't detect a binary diff")
+	}
+	if _, _, err := ParseDiffHunks("@@ -1,2 +1,2 @@\n a\n"); err == nil {
+		t.Errorf("ParseDiffHunks returned no error for a short hunk")
+	}
+}
+
+func TestParsedDiffLines(t *testing.T) {
+	d, err := Diff{OldPath: "main.go", NewPath: "main.go", AMode: "100644", BMode: "100755", Diff: testUnifiedDiff}.Parse()
+	if err != nil {
+		t.Fatalf("Diff.Parse returned error: %v", err)
+	}
+	if !d.ModeChanged() {
+		t.Errorf("ModeChanged returned false")
+	}
+
+	tests := []struct {
+		line     *DiffLine
+		old, new int
+	}{
+		{d.NewLine(2), 0, 2},    // added
+		{d.NewLine(4), 3, 4},    // context in a hunk
+		{d.NewLine(8), 7, 8},    // unchanged line between the hunks
+		{d.OldLine(11), 11, 0},  // removed
+		{d.NewLine(20), 20, 20}, // unchanged line after the hunks
+	}
+	for i, tt := range tests {
+		if tt.line == nil || tt.line.OldLine != tt.old || tt.line.NewLine != tt.new {
+			t.Errorf("%d: got line %+v, want old %d and new %d", i, tt.line, tt.old, tt.new)
+		}
+	}
+
+	pos := d.Position(d.NewLine(2), "base", "start", "head")
+	want := &NotePosition{BaseSHA: "base", StartSHA: "start", HeadSHA: "head", PositionType: "text", OldPath: "main.go", NewPath: "main.go", NewLine: 2}
+	if !reflect.DeepEqual(pos, want) {
+		t.Errorf("Position returned %+v, want %+v", pos, want)
+	}
+
+	// Hunks without lines on one side start at the line before.
+	d, _ = Diff{Diff: "@@ -5,2 +4,0 @@\n-a\n-b\n"}.Parse()
+	if l := d.NewLine(5); l == nil || l.OldLine != 7 {
+		t.Errorf("NewLine(5) returned %+v, want old line 7", l)
+	}
+}
+
+func TestMergeRequestNotePosition(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/merge_requests/1/changes", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"iid":1,"diff_refs":{"base_sha":"b","head_sha":"h","start_sha":"s"},"changes":[
+			{"old_path":"README.md","new_path":"README.md","a_mode":"100644","b_mode":"100644","diff":"@@ -1 +1 @@\n-old\n+new\n"}
+		]}`)
+	})
+
+	mr, _, err := client.MergeRequests.GetMergeRequestChanges(1, 1)
+	if err != nil {
+		t.Fatalf("MergeRequests.GetMergeRequestChanges returned error: %v", err)
+	}
+	diffs, err := mr.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges returned error: %v", err)
+	}
+
+	pos := mr.NotePosition(diffs[0], diffs[0].OldLine(1))
+	if pos.OldLine != 1 || pos.NewLine != 0 || pos.BaseSHA != "b" || pos.StartSHA != "s" || pos.HeadSHA != "h" {
+		t.Errorf("NotePosition returned %+v", pos)
+	}
+}
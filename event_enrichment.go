@@ -0,0 +1,390 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// webhookTimeLayouts contains the different time formats used in webhook
+// payloads, depending on the GitLab version and event type.
+var webhookTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05 -0700",
+}
+
+// parseWebhookTime parses a webhook timestamp, returning nil if the value
+// is empty or in an unknown format.
+func parseWebhookTime(value string) *time.Time {
+	for _, layout := range webhookTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// MergeRequest converts the merge event into a partial MergeRequest without
+// calling the API. Only the fields that are shared between both shapes are
+// set: ID, IID, ProjectID (the target project), SourceProjectID,
+// TargetProjectID, SourceBranch, TargetBranch, Title, Description, State,
+// MergeStatus, WorkInProgress, MergeCommitSHA, SHA (the last commit),
+// WebURL, CreatedAt, UpdatedAt and the author and assignee IDs and names
+// where the payload includes them. Use WebhookEnricher.MergeRequest to get
+// the full object.
+func (e *MergeEvent) MergeRequest() *MergeRequest {
+	a := e.ObjectAttributes
+
+	mr := &MergeRequest{
+		ID:              a.ID,
+		IID:             a.IID,
+		ProjectID:       a.TargetProjectID,
+		SourceProjectID: a.SourceProjectID,
+		TargetProjectID: a.TargetProjectID,
+		SourceBranch:    a.SourceBranch,
+		TargetBranch:    a.TargetBranch,
+		Title:           a.Title,
+		Description:     a.Description,
+		State:           a.State,
+		MergeStatus:     a.MergeStatus,
+		WorkInProgress:  a.WorkInProgress,
+		MergeCommitSHA:  a.MergeCommitSHA,
+		SHA:             a.LastCommit.ID,
+		WebURL:          a.URL,
+		CreatedAt:       parseWebhookTime(a.CreatedAt),
+		UpdatedAt:       parseWebhookTime(a.UpdatedAt),
+	}
+
+	mr.Author.ID = a.AuthorID
+	mr.Assignee.ID = a.AssigneeID
+	mr.Assignee.Name = e.Assignee.Name
+	mr.Assignee.Username = e.Assignee.Username
+
+	return mr
+}
+
+// Pipeline converts the pipeline event into a partial Pipeline without
+// calling the API. Only the fields that are shared between both shapes are
+// set: ID, Ref, Tag, SHA, BeforeSHA, Status, CreatedAt, FinishedAt,
+// Duration and the user name, username and avatar. Use
+// WebhookEnricher.Pipeline to get the full object.
+func (e *PipelineEvent) Pipeline() *Pipeline {
+	a := e.ObjectAttributes
+
+	p := &Pipeline{
+		ID:         a.ID,
+		Ref:        a.Ref,
+		Tag:        a.Tag,
+		SHA:        a.SHA,
+		BeforeSHA:  a.BeforeSHA,
+		Status:     a.Status,
+		CreatedAt:  parseWebhookTime(a.CreatedAt),
+		FinishedAt: parseWebhookTime(a.FinishedAt),
+		Duration:   a.Duration,
+	}
+
+	p.User.Name = e.User.Name
+	p.User.Username = e.User.Username
+	p.User.AvatarURL = e.User.AvatarURL
+
+	return p
+}
+
+// Jobs converts the builds of the pipeline event into partial Jobs without
+// calling the API. Only the fields that are shared between both shapes are
+// set: ID, Name, Stage, Status, CreatedAt, StartedAt, FinishedAt, the
+// runner, the artifacts file, the user name, username and avatar, and the
+// pipeline ID, ref, SHA and status.
+func (e *PipelineEvent) Jobs() []*Job {
+	var jobs []*Job
+	for _, b := range e.Builds {
+		j := &Job{
+			ID:         b.ID,
+			Name:       b.Name,
+			Stage:      b.Stage,
+			Status:     b.Status,
+			Ref:        e.ObjectAttributes.Ref,
+			Tag:        e.ObjectAttributes.Tag,
+			CreatedAt:  parseWebhookTime(b.CreatedAt),
+			StartedAt:  parseWebhookTime(b.StartedAt),
+			FinishedAt: parseWebhookTime(b.FinishedAt),
+			User: &User{
+				Name:      b.User.Name,
+				Username:  b.User.Username,
+				AvatarURL: b.User.AvatarURL,
+			},
+		}
+		j.Pipeline.ID = e.ObjectAttributes.ID
+		j.Pipeline.Ref = e.ObjectAttributes.Ref
+		j.Pipeline.Sha = e.ObjectAttributes.SHA
+		j.Pipeline.Status = e.ObjectAttributes.Status
+		j.Runner.ID = b.Runner.ID
+		j.Runner.Description = b.Runner.Description
+		j.Runner.Active = b.Runner.Active
+		j.Runner.IsShared = b.Runner.IsShared
+		j.ArtifactsFile.Filename = b.ArtifactsFile.Filename
+		j.ArtifactsFile.Size = b.ArtifactsFile.Size
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+// EnrichedPipeline represents a pipeline together with its jobs.
+type EnrichedPipeline struct {
+	Pipeline *Pipeline
+	Jobs     []*Job
+}
+
+// WebhookEnricher fetches the full API objects that webhook events refer
+// to. Results are cached per object and per change, so handlers receiving
+// the same event multiple times, or receiving several events for the same
+// state of an object, only trigger a single API call. Pipelines are only
+// cached once they reached a terminal status, as their jobs may still
+// change otherwise.
+type WebhookEnricher struct {
+	client *Client
+
+	// Concurrency limits the number of concurrent API calls made by
+	// EnrichAll. Defaults to 4.
+	Concurrency int
+
+	// CacheSize limits the number of cached objects. When the limit is
+	// reached the oldest objects are evicted first. Defaults to 1000.
+	CacheSize int
+
+	mu    sync.Mutex
+	cache map[string]interface{}
+	keys  []string
+}
+
+// NewWebhookEnricher returns a new WebhookEnricher using the given client.
+func NewWebhookEnricher(client *Client) *WebhookEnricher {
+	return &WebhookEnricher{
+		client:      client,
+		Concurrency: 4,
+		CacheSize:   1000,
+		cache:       make(map[string]interface{}),
+	}
+}
+
+// cached returns the cached object for key, or calls fetch to get it. The
+// result is only stored when fetch reports it as cacheable.
+func (e *WebhookEnricher) cached(key string, fetch func() (interface{}, bool, error)) (interface{}, error) {
+	e.mu.Lock()
+	v, ok := e.cache[key]
+	e.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	v, cacheable, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if !cacheable {
+		return v, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.cache[key]; !ok {
+		e.keys = append(e.keys, key)
+	}
+	e.cache[key] = v
+
+	size := e.CacheSize
+	if size <= 0 {
+		size = 1
+	}
+	for len(e.keys) > size {
+		delete(e.cache, e.keys[0])
+		e.keys = e.keys[1:]
+	}
+
+	return v, nil
+}
+
+// Purge removes all cached objects.
+func (e *WebhookEnricher) Purge() {
+	e.mu.Lock()
+	e.cache = make(map[string]interface{})
+	e.keys = nil
+	e.mu.Unlock()
+}
+
+// MergeRequest returns the merge request the merge event refers to.
+func (e *WebhookEnricher) MergeRequest(event *MergeEvent, options ...OptionFunc) (*MergeRequest, error) {
+	a := event.ObjectAttributes
+	return e.mergeRequest(a.TargetProjectID, a.IID, a.UpdatedAt, options...)
+}
+
+// MergeRequestForComment returns the merge request the comment event refers
+// to.
+func (e *WebhookEnricher) MergeRequestForComment(event *MergeCommentEvent, options ...OptionFunc) (*MergeRequest, error) {
+	mr := event.MergeRequest
+	return e.mergeRequest(mr.TargetProjectID, mr.IID, mr.UpdatedAt, options...)
+}
+
+func (e *WebhookEnricher) mergeRequest(pid, iid int, version string, options ...OptionFunc) (*MergeRequest, error) {
+	key := fmt.Sprintf("merge_request/%d/%d@%s", pid, iid, version)
+	v, err := e.cached(key, func() (interface{}, bool, error) {
+		mr, _, err := e.client.MergeRequests.GetMergeRequest(pid, iid, nil, options...)
+		return mr, true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*MergeRequest), nil
+}
+
+// Issue returns the issue the issue event refers to.
+func (e *WebhookEnricher) Issue(event *IssueEvent, options ...OptionFunc) (*Issue, error) {
+	a := event.ObjectAttributes
+	return e.issue(a.ProjectID, a.IID, a.UpdatedAt, options...)
+}
+
+func (e *WebhookEnricher) issue(pid, iid int, version string, options ...OptionFunc) (*Issue, error) {
+	key := fmt.Sprintf("issue/%d/%d@%s", pid, iid, version)
+	v, err := e.cached(key, func() (interface{}, bool, error) {
+		issue, _, err := e.client.Issues.GetIssue(pid, iid, options...)
+		return issue, true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Issue), nil
+}
+
+// Pipeline returns the pipeline the pipeline event refers to, together with
+// all of its jobs.
+func (e *WebhookEnricher) Pipeline(event *PipelineEvent, options ...OptionFunc) (*EnrichedPipeline, error) {
+	pid := event.Project.ID
+	a := event.ObjectAttributes
+
+	key := fmt.Sprintf("pipeline/%d/%d@%s", pid, a.ID, a.Status)
+	v, err := e.cached(key, func() (interface{}, bool, error) {
+		p, _, err := e.client.Pipelines.GetPipeline(pid, a.ID, options...)
+		if err != nil {
+			return nil, false, err
+		}
+
+		ep := &EnrichedPipeline{Pipeline: p}
+
+		opt := &ListJobsOptions{ListOptions: ListOptions{Page: 1, PerPage: 100}}
+		for {
+			jobs, resp, err := e.client.Jobs.ListPipelineJobs(pid, a.ID, opt, options...)
+			if err != nil {
+				return nil, false, err
+			}
+			ep.Jobs = append(ep.Jobs, jobs...)
+
+			if resp.NextPage == 0 {
+				break
+			}
+			opt.Page = resp.NextPage
+		}
+
+		return ep, IsTerminalPipelineStatus(p.Status), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*EnrichedPipeline), nil
+}
+
+// Enrich returns the full API object for a single webhook event, as
+// returned by ParseWebhook. It returns a *MergeRequest for merge events and
+// merge request comments, an *Issue for issue events and an
+// *EnrichedPipeline for pipeline events. Other events are returned as is.
+func (e *WebhookEnricher) Enrich(event interface{}, options ...OptionFunc) (interface{}, error) {
+	switch event := event.(type) {
+	case *MergeEvent:
+		return e.MergeRequest(event, options...)
+	case *MergeCommentEvent:
+		return e.MergeRequestForComment(event, options...)
+	case *IssueEvent:
+		return e.Issue(event, options...)
+	case *PipelineEvent:
+		return e.Pipeline(event, options...)
+	default:
+		return event, nil
+	}
+}
+
+// EnrichAll enriches a batch of webhook events concurrently. The returned
+// slice contains the enriched objects in the same order as the events.
+// Events referring to the same object state are only fetched once. The
+// first error encountered is returned.
+func (e *WebhookEnricher) EnrichAll(events []interface{}, options ...OptionFunc) ([]interface{}, error) {
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]interface{}, len(events))
+	errs := make([]error, len(events))
+
+	// Serialize requests for the same object so the cache is hit.
+	var keyLocks sync.Map
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, event := range events {
+		wg.Add(1)
+		go func(i int, event interface{}) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			l, _ := keyLocks.LoadOrStore(enrichKey(event), &sync.Mutex{})
+			l.(*sync.Mutex).Lock()
+			defer l.(*sync.Mutex).Unlock()
+
+			results[i], errs[i] = e.Enrich(event, options...)
+		}(i, event)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+func enrichKey(event interface{}) string {
+	switch event := event.(type) {
+	case *MergeEvent:
+		return fmt.Sprintf("merge_request/%d/%d", event.ObjectAttributes.TargetProjectID, event.ObjectAttributes.IID)
+	case *MergeCommentEvent:
+		return fmt.Sprintf("merge_request/%d/%d", event.MergeRequest.TargetProjectID, event.MergeRequest.IID)
+	case *IssueEvent:
+		return fmt.Sprintf("issue/%d/%d", event.ObjectAttributes.ProjectID, event.ObjectAttributes.IID)
+	case *PipelineEvent:
+		return fmt.Sprintf("pipeline/%d/%d", event.Project.ID, event.ObjectAttributes.ID)
+	default:
+		return fmt.Sprintf("%p", event)
+	}
+}
@@ -0,0 +1,129 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWebhookEnricherEnrichAll(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mrCalls := 0
+	mux.HandleFunc("/api/v4/projects/1/merge_requests/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		mrCalls++
+		fmt.Fprint(w, `{"id":20,"iid":2,"project_id":1,"title":"Full"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/3", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":3,"status":"success"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/3/jobs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"id":4},{"id":5}]`)
+	})
+
+	mergeEvent := &MergeEvent{}
+	mergeEvent.ObjectAttributes.TargetProjectID = 1
+	mergeEvent.ObjectAttributes.IID = 2
+	mergeEvent.ObjectAttributes.UpdatedAt = "2019-01-01 10:00:00 UTC"
+
+	pipelineEvent := &PipelineEvent{}
+	pipelineEvent.Project.ID = 1
+	pipelineEvent.ObjectAttributes.ID = 3
+	pipelineEvent.ObjectAttributes.Status = "success"
+
+	enricher := NewWebhookEnricher(client)
+	results, err := enricher.EnrichAll([]interface{}{mergeEvent, pipelineEvent, mergeEvent})
+	if err != nil {
+		t.Fatalf("WebhookEnricher.EnrichAll returned error: %v", err)
+	}
+
+	if mr, ok := results[0].(*MergeRequest); !ok || mr.Title != "Full" {
+		t.Errorf("WebhookEnricher.EnrichAll returned %+v, want merge request", results[0])
+	}
+	if p, ok := results[1].(*EnrichedPipeline); !ok || p.Pipeline.ID != 3 || len(p.Jobs) != 2 {
+		t.Errorf("WebhookEnricher.EnrichAll returned %+v, want pipeline with 2 jobs", results[1])
+	}
+	if mrCalls != 1 {
+		t.Errorf("WebhookEnricher.EnrichAll fetched the merge request %d times, want 1", mrCalls)
+	}
+}
+
+func TestWebhookEnricherCache(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	pipelineCalls := 0
+	mux.HandleFunc("/api/v4/projects/1/pipelines/3", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		pipelineCalls++
+		fmt.Fprint(w, `{"id":3,"status":"running"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/3/jobs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"id":4}]`)
+	})
+	issueCalls := 0
+	mux.HandleFunc("/api/v4/projects/1/issues/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		issueCalls++
+		fmt.Fprint(w, `{"id":10,"iid":1}`)
+	})
+
+	pipelineEvent := &PipelineEvent{}
+	pipelineEvent.Project.ID = 1
+	pipelineEvent.ObjectAttributes.ID = 3
+	pipelineEvent.ObjectAttributes.Status = "running"
+
+	enricher := NewWebhookEnricher(client)
+	enricher.CacheSize = 2
+
+	for i := 0; i < 2; i++ {
+		if _, err := enricher.Pipeline(pipelineEvent); err != nil {
+			t.Fatalf("WebhookEnricher.Pipeline returned error: %v", err)
+		}
+	}
+	if pipelineCalls != 2 {
+		t.Errorf("WebhookEnricher.Pipeline fetched a running pipeline %d times, want 2", pipelineCalls)
+	}
+
+	issueEvent := func(iid int) *IssueEvent {
+		event := &IssueEvent{}
+		event.ObjectAttributes.ProjectID = 1
+		event.ObjectAttributes.IID = iid
+		return event
+	}
+	for _, iid := range []int{1, 2, 3, 3, 1} {
+		if _, err := enricher.Issue(issueEvent(iid)); err != nil {
+			t.Fatalf("WebhookEnricher.Issue returned error: %v", err)
+		}
+	}
+	if issueCalls != 4 {
+		t.Errorf("WebhookEnricher.Issue fetched issues %d times, want 4", issueCalls)
+	}
+}
+
+func TestMergeEventMergeRequest(t *testing.T) {
+	event := &MergeEvent{}
+	event.ObjectAttributes.IID = 2
+	event.ObjectAttributes.TargetProjectID = 1
+	event.ObjectAttributes.CreatedAt = "2013-12-03T17:23:34Z"
+	event.ObjectAttributes.UpdatedAt = "2013-12-03 17:23:34 UTC"
+
+	mr := event.MergeRequest()
+	if mr.IID != 2 || mr.ProjectID != 1 {
+		t.Errorf("MergeEvent.MergeRequest returned %+v", mr)
+	}
+
+	want := time.Date(2013, 12, 3, 17, 23, 34, 0, time.UTC)
+	if mr.CreatedAt == nil || !mr.CreatedAt.Equal(want) {
+		t.Errorf("MergeEvent.MergeRequest returned created at %v, want %v", mr.CreatedAt, want)
+	}
+	if mr.UpdatedAt == nil || !mr.UpdatedAt.Equal(want) {
+		t.Errorf("MergeEvent.MergeRequest returned updated at %v, want %v", mr.UpdatedAt, want)
+	}
+}
@@ -0,0 +1,413 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventSourceFunc lists contribution events. The List methods of the
+// EventsService can be used as an EventSourceFunc directly, or wrapped in a
+// closure when they take an additional project or user argument.
+type EventSourceFunc func(opt *ListContributionEventsOptions, options ...OptionFunc) ([]*ContributionEvent, *Response, error)
+
+// EventPollerCursor represents the persistable state of an EventPoller. It
+// can be marshaled to JSON, stored and passed back in EventPollerOptions to
+// resume polling without emitting events that were already seen.
+type EventPollerCursor struct {
+	// HighWaterMark is the creation time of the newest event seen so far.
+	HighWaterMark *time.Time `json:"high_water_mark"`
+
+	// Seen contains the keys of the events created at HighWaterMark, which
+	// are returned again when the next poll overlaps with the previous one.
+	Seen []string `json:"seen"`
+}
+
+// EventPollerOptions represents the available NewEventPoller() options.
+type EventPollerOptions struct {
+	// Interval between two polls. Defaults to one minute.
+	Interval time.Duration
+
+	// PerPage is the page size used when listing events. Defaults to 100.
+	PerPage int
+
+	// Action and TargetType limit the events to the given action and
+	// target type.
+	Action     *EventTypeValue
+	TargetType *EventTargetTypeValue
+
+	// Cursor is the state to resume polling from. When nil, the first poll
+	// emits all events of the last day.
+	Cursor *EventPollerCursor
+}
+
+// EventPoller polls a contribution event feed and emits every event only
+// once. It can be used as an alternative to webhooks when GitLab is unable
+// to reach the consumer of the events.
+type EventPoller struct {
+	source   EventSourceFunc
+	interval time.Duration
+	perPage  int
+	action   *EventTypeValue
+	target   *EventTargetTypeValue
+
+	mu     sync.Mutex
+	cursor EventPollerCursor
+	seen   map[string]bool
+}
+
+// NewEventPoller returns an EventPoller for the given event source.
+func NewEventPoller(source EventSourceFunc, opt *EventPollerOptions) *EventPoller {
+	if opt == nil {
+		opt = &EventPollerOptions{}
+	}
+
+	p := &EventPoller{
+		source:   source,
+		interval: opt.Interval,
+		perPage:  opt.PerPage,
+		action:   opt.Action,
+		target:   opt.TargetType,
+		seen:     make(map[string]bool),
+	}
+	if p.interval <= 0 {
+		p.interval = time.Minute
+	}
+	if p.perPage <= 0 {
+		p.perPage = 100
+	}
+
+	if opt.Cursor != nil {
+		p.cursor.HighWaterMark = opt.Cursor.HighWaterMark
+		for _, key := range opt.Cursor.Seen {
+			p.seen[key] = true
+		}
+	}
+
+	return p
+}
+
+// NewCurrentUserEventPoller returns an EventPoller for the events of the
+// currently authenticated user.
+func (s *EventsService) NewCurrentUserEventPoller(opt *EventPollerOptions) *EventPoller {
+	return NewEventPoller(s.ListCurrentUserContributionEvents, opt)
+}
+
+// NewProjectEventPoller returns an EventPoller for the visible events of the
+// given project.
+func (s *EventsService) NewProjectEventPoller(pid interface{}, opt *EventPollerOptions) *EventPoller {
+	return NewEventPoller(func(o *ListContributionEventsOptions, options ...OptionFunc) ([]*ContributionEvent, *Response, error) {
+		return s.ListProjectVisibleEvents(pid, o, options...)
+	}, opt)
+}
+
+// NewUserEventPoller returns an EventPoller for the contribution events of
+// the given user.
+func (s *UsersService) NewUserEventPoller(uid interface{}, opt *EventPollerOptions) *EventPoller {
+	return NewEventPoller(func(o *ListContributionEventsOptions, options ...OptionFunc) ([]*ContributionEvent, *Response, error) {
+		return s.ListUserContributionEvents(uid, o, options...)
+	}, opt)
+}
+
+// Cursor returns a copy of the current state of the poller.
+func (p *EventPoller) Cursor() *EventPollerCursor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c := &EventPollerCursor{}
+	if p.cursor.HighWaterMark != nil {
+		t := *p.cursor.HighWaterMark
+		c.HighWaterMark = &t
+	}
+	for key := range p.seen {
+		c.Seen = append(c.Seen, key)
+	}
+	sort.Strings(c.Seen)
+
+	return c
+}
+
+// Poll fetches all events since the high-water mark and returns the ones
+// that were not returned before, ordered from oldest to newest.
+func (p *EventPoller) Poll(options ...OptionFunc) ([]*ContributionEvent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The API only filters on dates, so we always ask for everything since
+	// the day before the high-water mark and filter the overlap ourselves.
+	after := ISOTime(time.Now().AddDate(0, 0, -2))
+	if p.cursor.HighWaterMark != nil {
+		after = ISOTime(p.cursor.HighWaterMark.AddDate(0, 0, -1))
+	}
+
+	opt := &ListContributionEventsOptions{
+		ListOptions: ListOptions{Page: 1, PerPage: p.perPage},
+		Action:      p.action,
+		TargetType:  p.target,
+		After:       &after,
+		Sort:        String("asc"),
+	}
+
+	var events []*ContributionEvent
+	for {
+		es, resp, err := p.source(opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, es...)
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	// Pages can shift while we are paging, so sort before filtering.
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+
+	var fresh []*ContributionEvent
+	for _, e := range events {
+		created := eventTime(e)
+		key := e.key()
+
+		if mark := p.cursor.HighWaterMark; mark != nil {
+			if created.Before(*mark) || (created.Equal(*mark) && p.seen[key]) {
+				continue
+			}
+			if created.After(*mark) {
+				p.seen = make(map[string]bool)
+			}
+		}
+
+		p.seen[key] = true
+		p.cursor.HighWaterMark = &created
+		fresh = append(fresh, e)
+	}
+
+	return fresh, nil
+}
+
+// Run polls the event feed until the context is canceled and sends every
+// new event to the given channel. It returns the error of the first failed
+// poll, or the context error once the context is done.
+func (p *EventPoller) Run(ctx context.Context, events chan<- *ContributionEvent) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		es, err := p.Poll(WithContext(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		for _, e := range es {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func eventTime(e *ContributionEvent) time.Time {
+	if e.CreatedAt == nil {
+		return time.Time{}
+	}
+	return *e.CreatedAt
+}
+
+// key returns a key that uniquely identifies the event. Older GitLab
+// versions do not return event IDs, in which case the key is composed of
+// the fields that together identify an event.
+func (e *ContributionEvent) key() string {
+	if e.ID != 0 {
+		return fmt.Sprintf("id:%d", e.ID)
+	}
+	return fmt.Sprintf("%d:%d:%s:%s:%d:%s:%s",
+		e.ProjectID,
+		e.AuthorID,
+		e.ActionName,
+		e.TargetType,
+		e.TargetID,
+		e.PushData.CommitTo,
+		eventTime(e).Format(time.RFC3339Nano),
+	)
+}
+
+// WebhookEvent maps the contribution event to the struct type that would
+// have been returned by ParseWebhook for the same event, populated with the
+// fields that are available in the contribution event. It returns false if
+// the event has no webhook counterpart.
+func (e *ContributionEvent) WebhookEvent() (interface{}, bool) {
+	user := &User{
+		ID:        e.Author.ID,
+		Name:      e.Author.Name,
+		Username:  e.Author.Username,
+		State:     e.Author.State,
+		AvatarURL: e.Author.AvatarURL,
+	}
+
+	switch {
+	case e.PushData.CommitTo != "" || e.PushData.CommitFrom != "" || e.PushData.Ref != "":
+		if e.PushData.RefType == "tag" {
+			event := &TagEvent{
+				ObjectKind:        "tag_push",
+				Before:            e.PushData.CommitFrom,
+				After:             e.PushData.CommitTo,
+				Ref:               "refs/tags/" + e.PushData.Ref,
+				CheckoutSHA:       e.PushData.CommitTo,
+				UserID:            e.AuthorID,
+				UserName:          e.Author.Name,
+				UserAvatar:        e.Author.AvatarURL,
+				ProjectID:         e.ProjectID,
+				TotalCommitsCount: e.PushData.CommitCount,
+			}
+			return event, true
+		}
+
+		event := &PushEvent{
+			ObjectKind:        "push",
+			Before:            e.PushData.CommitFrom,
+			After:             e.PushData.CommitTo,
+			Ref:               "refs/heads/" + e.PushData.Ref,
+			CheckoutSHA:       e.PushData.CommitTo,
+			UserID:            e.AuthorID,
+			UserName:          e.Author.Name,
+			UserAvatar:        e.Author.AvatarURL,
+			ProjectID:         e.ProjectID,
+			TotalCommitsCount: e.PushData.CommitCount,
+		}
+		return event, true
+
+	case e.Note != nil:
+		return e.noteWebhookEvent(user)
+
+	case e.TargetType == "Issue":
+		event := &IssueEvent{ObjectKind: "issue", User: user}
+		event.ObjectAttributes.ID = e.TargetID
+		event.ObjectAttributes.IID = e.TargetIID
+		event.ObjectAttributes.Title = e.TargetTitle
+		event.ObjectAttributes.AuthorID = e.AuthorID
+		event.ObjectAttributes.ProjectID = e.ProjectID
+		event.ObjectAttributes.Action = webhookAction(e.ActionName)
+		return event, true
+
+	case e.TargetType == "MergeRequest":
+		event := &MergeEvent{ObjectKind: "merge_request", User: user}
+		event.Project.ID = e.ProjectID
+		event.ObjectAttributes.ID = e.TargetID
+		event.ObjectAttributes.IID = e.TargetIID
+		event.ObjectAttributes.Title = e.TargetTitle
+		event.ObjectAttributes.AuthorID = e.AuthorID
+		event.ObjectAttributes.TargetProjectID = e.ProjectID
+		event.ObjectAttributes.Action = webhookAction(e.ActionName)
+		return event, true
+	}
+
+	return nil, false
+}
+
+func (e *ContributionEvent) noteWebhookEvent(user *User) (interface{}, bool) {
+	n := e.Note
+
+	switch n.NoteableType {
+	case noteableTypeCommit:
+		event := &CommitCommentEvent{ObjectKind: "note", User: user, ProjectID: e.ProjectID}
+		event.ObjectAttributes.ID = n.ID
+		event.ObjectAttributes.Note = n.Body
+		event.ObjectAttributes.NoteableType = n.NoteableType
+		event.ObjectAttributes.AuthorID = n.Author.ID
+		event.ObjectAttributes.ProjectID = e.ProjectID
+		event.ObjectAttributes.NoteableID = n.NoteableID
+		event.ObjectAttributes.System = n.System
+		return event, true
+
+	case noteableTypeMergeRequest:
+		event := &MergeCommentEvent{ObjectKind: "note", User: user, ProjectID: e.ProjectID}
+		event.ObjectAttributes.ID = n.ID
+		event.ObjectAttributes.Note = n.Body
+		event.ObjectAttributes.NoteableType = n.NoteableType
+		event.ObjectAttributes.AuthorID = n.Author.ID
+		event.ObjectAttributes.ProjectID = e.ProjectID
+		event.ObjectAttributes.NoteableID = n.NoteableID
+		event.ObjectAttributes.System = n.System
+		event.MergeRequest.ID = n.NoteableID
+		event.MergeRequest.IID = n.NoteableIID
+		event.MergeRequest.TargetProjectID = e.ProjectID
+		return event, true
+
+	case noteableTypeIssue:
+		event := &IssueCommentEvent{ObjectKind: "note", User: user, ProjectID: e.ProjectID}
+		event.ObjectAttributes.ID = n.ID
+		event.ObjectAttributes.Note = n.Body
+		event.ObjectAttributes.NoteableType = n.NoteableType
+		event.ObjectAttributes.AuthorID = n.Author.ID
+		event.ObjectAttributes.ProjectID = e.ProjectID
+		event.ObjectAttributes.NoteableID = n.NoteableID
+		event.ObjectAttributes.System = n.System
+		event.Issue = &Issue{ID: n.NoteableID, IID: n.NoteableIID, ProjectID: e.ProjectID}
+		return event, true
+
+	case noteableTypeSnippet:
+		event := &SnippetCommentEvent{ObjectKind: "note", User: user, ProjectID: e.ProjectID}
+		event.ObjectAttributes.ID = n.ID
+		event.ObjectAttributes.Note = n.Body
+		event.ObjectAttributes.NoteableType = n.NoteableType
+		event.ObjectAttributes.AuthorID = n.Author.ID
+		event.ObjectAttributes.ProjectID = e.ProjectID
+		event.ObjectAttributes.NoteableID = n.NoteableID
+		event.ObjectAttributes.System = n.System
+		event.Snippet = &Snippet{ID: n.NoteableID}
+		return event, true
+	}
+
+	return nil, false
+}
+
+// webhookAction maps the action name of a contribution event to the action
+// used in the object attributes of webhook events.
+func webhookAction(action string) string {
+	switch action {
+	case "opened":
+		return "open"
+	case "closed":
+		return "close"
+	case "reopened":
+		return "reopen"
+	case "updated":
+		return "update"
+	case "accepted":
+		return "merge"
+	}
+	return action
+}
@@ -0,0 +1,112 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestEventPollerPoll(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	polls := 0
+	mux.HandleFunc("/api/v4/projects/1/events", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.Query().Get("sort"); got != "asc" {
+			t.Errorf("Request sort: %s, want asc", got)
+		}
+
+		switch {
+		case polls == 0 && r.URL.Query().Get("page") == "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"id":1,"created_at":"2019-01-01T10:00:00Z"}]`)
+		case polls == 0:
+			polls++
+			fmt.Fprint(w, `[{"id":2,"created_at":"2019-01-01T11:00:00Z"}]`)
+		default:
+			fmt.Fprint(w, `[{"id":2,"created_at":"2019-01-01T11:00:00Z"},{"id":3,"created_at":"2019-01-01T11:00:00Z"}]`)
+		}
+	})
+
+	poller := client.Events.NewProjectEventPoller(1, nil)
+
+	events, err := poller.Poll()
+	if err != nil {
+		t.Fatalf("EventPoller.Poll returned error: %v", err)
+	}
+	if got := eventIDs(events); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("EventPoller.Poll returned %v, want %v", got, []int{1, 2})
+	}
+
+	cursor := poller.Cursor()
+	if !reflect.DeepEqual(cursor.Seen, []string{"id:2"}) {
+		t.Errorf("EventPoller.Cursor returned seen %v, want %v", cursor.Seen, []string{"id:2"})
+	}
+
+	// A new poller resumed from the cursor must skip the overlapping event.
+	poller = client.Events.NewProjectEventPoller(1, &EventPollerOptions{Cursor: cursor})
+
+	events, err = poller.Poll()
+	if err != nil {
+		t.Fatalf("EventPoller.Poll returned error: %v", err)
+	}
+	if got := eventIDs(events); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("EventPoller.Poll returned %v, want %v", got, []int{3})
+	}
+}
+
+func TestContributionEventWebhookEvent(t *testing.T) {
+	e := &ContributionEvent{
+		ProjectID:  1,
+		ActionName: "pushed to",
+		AuthorID:   2,
+	}
+	e.PushData.Ref = "master"
+	e.PushData.RefType = "branch"
+	e.PushData.CommitFrom = "a"
+	e.PushData.CommitTo = "b"
+	e.PushData.CommitCount = 3
+
+	event, ok := e.WebhookEvent()
+	if !ok {
+		t.Fatal("ContributionEvent.WebhookEvent returned no event")
+	}
+
+	want := &PushEvent{
+		ObjectKind:        "push",
+		Before:            "a",
+		After:             "b",
+		Ref:               "refs/heads/master",
+		CheckoutSHA:       "b",
+		UserID:            2,
+		ProjectID:         1,
+		TotalCommitsCount: 3,
+	}
+	if !reflect.DeepEqual(want, event) {
+		t.Errorf("ContributionEvent.WebhookEvent returned %+v, want %+v", event, want)
+	}
+
+	e = &ContributionEvent{TargetType: "MergeRequest", TargetIID: 5, ActionName: "opened"}
+	event, ok = e.WebhookEvent()
+	if !ok {
+		t.Fatal("ContributionEvent.WebhookEvent returned no event")
+	}
+
+	mr, ok := event.(*MergeEvent)
+	if !ok {
+		t.Fatalf("ContributionEvent.WebhookEvent returned %T, want *MergeEvent", event)
+	}
+	if mr.ObjectAttributes.IID != 5 || mr.ObjectAttributes.Action != "open" {
+		t.Errorf("ContributionEvent.WebhookEvent returned %+v", mr.ObjectAttributes)
+	}
+}
+
+func eventIDs(events []*ContributionEvent) []int {
+	var ids []int
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
@@ -263,6 +263,19 @@ const (
 	UserEventTargetType         EventTargetTypeValue = "user"
 )
 
+// VariableTypeValue represents a variable type within GitLab.
+//
+// GitLab API docs: https://docs.gitlab.com/ce/api/project_level_variables.html
+type VariableTypeValue string
+
+// List of available variable types
+//
+// GitLab API docs: https://docs.gitlab.com/ce/api/project_level_variables.html
+const (
+	EnvVariableType  VariableTypeValue = "env_var"
+	FileVariableType VariableTypeValue = "file"
+)
+
 // A Client manages communication with the GitLab API.
 type Client struct {
 	// HTTP client used to communicate with the API.
@@ -285,6 +298,11 @@ type Client struct {
 	// User agent used when communicating with the GitLab API.
 	UserAgent string
 
+	// Strict decoding records the fields of responses that are not part of
+	// the type they are decoded into.
+	strictDecoding    bool
+	unknownFieldsFunc UnknownFieldsFunc
+
 	// Services used for talking to different parts of the GitLab API.
 	AccessRequests        *AccessRequestsService
 	AwardEmoji            *AwardEmojiService
@@ -332,6 +350,8 @@ type Client struct {
 	Projects              *ProjectsService
 	ProtectedBranches     *ProtectedBranchesService
 	ProtectedTags         *ProtectedTagsService
+	ReleaseLinks          *ReleaseLinksService
+	Releases              *ReleasesService
 	Repositories          *RepositoriesService
 	RepositoryFiles       *RepositoryFilesService
 	Runners               *RunnersService
@@ -474,6 +494,8 @@ func newClient(httpClient *http.Client) *Client {
 	c.Projects = &ProjectsService{client: c}
 	c.ProtectedBranches = &ProtectedBranchesService{client: c}
 	c.ProtectedTags = &ProtectedTagsService{client: c}
+	c.ReleaseLinks = &ReleaseLinksService{client: c}
+	c.Releases = &ReleasesService{client: c}
 	c.Repositories = &RepositoriesService{client: c}
 	c.RepositoryFiles = &RepositoryFilesService{client: c}
 	c.Runners = &RunnersService{client: c}
@@ -614,6 +636,11 @@ type Response struct {
 	CurrentPage  int
 	NextPage     int
 	PreviousPage int
+
+	// UnknownFields contains the fields of the response that were ignored
+	// while decoding it. It is only populated when strict decoding is
+	// enabled on the client.
+	UnknownFields []string
 }
 
 // newResponse creates a new Response for the provided http.Response.
@@ -687,6 +714,8 @@ func (c *Client) Do(req *http.Request, v interface{}) (*Response, error) {
 	if v != nil {
 		if w, ok := v.(io.Writer); ok {
 			_, err = io.Copy(w, resp.Body)
+		} else if c.strictDecoding {
+			err = c.decodeStrict(response, v)
 		} else {
 			err = json.NewDecoder(resp.Body).Decode(v)
 		}
@@ -727,7 +756,7 @@ func (e *ErrorResponse) Error() string {
 // CheckResponse checks the API response for errors, and returns them if present.
 func CheckResponse(r *http.Response) error {
 	switch r.StatusCode {
-	case 200, 201, 202, 204, 304:
+	case 200, 201, 202, 204, 206, 304:
 		return nil
 	}
 
@@ -881,6 +910,14 @@ func MergeMethod(v MergeMethodValue) *MergeMethodValue {
 	return p
 }
 
+// VariableType is a helper routine that allocates a new VariableTypeValue
+// to store v and returns a pointer to it.
+func VariableType(v VariableTypeValue) *VariableTypeValue {
+	p := new(VariableTypeValue)
+	*p = v
+	return p
+}
+
 // BoolValue is a boolean value with advanced json unmarshaling features.
 type BoolValue bool
 
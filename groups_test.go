@@ -204,3 +204,106 @@ func TestListSubgroups(t *testing.T) {
 		t.Errorf("Groups.ListSubgroups returned %+v, want %+v", groups, want)
 	}
 }
+
+func TestListGroupHooks(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/groups/1/hooks", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"id":1,"url":"http://example.com/hook","group_id":1,"push_events":true}]`)
+	})
+
+	hooks, _, err := client.Groups.ListGroupHooks(1, nil)
+	if err != nil {
+		t.Errorf("Groups.ListGroupHooks returned error: %v", err)
+	}
+
+	want := []*GroupHook{{ID: 1, URL: "http://example.com/hook", GroupID: 1, PushEvents: true}}
+	if !reflect.DeepEqual(want, hooks) {
+		t.Errorf("Groups.ListGroupHooks returned %+v, want %+v", hooks, want)
+	}
+}
+
+func TestAddGroupHook(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/groups/1/hooks", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testBody(t, r, `{"url":"http://example.com/hook","push_events":true}`)
+		fmt.Fprint(w, `{"id":1,"url":"http://example.com/hook","group_id":1,"push_events":true}`)
+	})
+
+	opt := &AddGroupHookOptions{URL: String("http://example.com/hook"), PushEvents: Bool(true)}
+	hook, _, err := client.Groups.AddGroupHook(1, opt)
+	if err != nil {
+		t.Errorf("Groups.AddGroupHook returned error: %v", err)
+	}
+
+	want := &GroupHook{ID: 1, URL: "http://example.com/hook", GroupID: 1, PushEvents: true}
+	if !reflect.DeepEqual(want, hook) {
+		t.Errorf("Groups.AddGroupHook returned %+v, want %+v", hook, want)
+	}
+}
+
+func TestGetGroupHook(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/groups/1/hooks/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":2,"url":"http://example.com/hook","group_id":1,"push_events":true}`)
+	})
+
+	hook, _, err := client.Groups.GetGroupHook(1, 2)
+	if err != nil {
+		t.Errorf("Groups.GetGroupHook returned error: %v", err)
+	}
+
+	want := &GroupHook{ID: 2, URL: "http://example.com/hook", GroupID: 1, PushEvents: true}
+	if !reflect.DeepEqual(want, hook) {
+		t.Errorf("Groups.GetGroupHook returned %+v, want %+v", hook, want)
+	}
+}
+
+func TestEditGroupHook(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/groups/1/hooks/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testBody(t, r, `{"url":"http://example.com/new","push_events":false}`)
+		fmt.Fprint(w, `{"id":2,"url":"http://example.com/new","group_id":1}`)
+	})
+
+	opt := &EditGroupHookOptions{URL: String("http://example.com/new"), PushEvents: Bool(false)}
+	hook, _, err := client.Groups.EditGroupHook(1, 2, opt)
+	if err != nil {
+		t.Errorf("Groups.EditGroupHook returned error: %v", err)
+	}
+
+	want := &GroupHook{ID: 2, URL: "http://example.com/new", GroupID: 1}
+	if !reflect.DeepEqual(want, hook) {
+		t.Errorf("Groups.EditGroupHook returned %+v, want %+v", hook, want)
+	}
+}
+
+func TestDeleteGroupHook(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/groups/1/hooks/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	resp, err := client.Groups.DeleteGroupHook(1, 2)
+	if err != nil {
+		t.Errorf("Groups.DeleteGroupHook returned error: %v", err)
+	}
+
+	if want := http.StatusNoContent; resp.StatusCode != want {
+		t.Errorf("Groups.DeleteGroupHook returned status %d, want %d", resp.StatusCode, want)
+	}
+}
@@ -0,0 +1,388 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"sync"
+)
+
+// HookSpec describes the desired state of a project or group hook.
+type HookSpec struct {
+	URL                      string
+	Token                    string
+	PushEvents               bool
+	PushEventsBranchFilter   string
+	IssuesEvents             bool
+	ConfidentialIssuesEvents bool
+	MergeRequestsEvents      bool
+	TagPushEvents            bool
+	NoteEvents               bool
+	ConfidentialNoteEvents   bool
+	JobEvents                bool
+	PipelineEvents           bool
+	WikiPageEvents           bool
+	EnableSSLVerification    bool
+}
+
+// HookActionValue represents the action taken for a hook during
+// reconciliation.
+type HookActionValue string
+
+// List of available hook actions.
+const (
+	HookCreated   HookActionValue = "created"
+	HookUpdated   HookActionValue = "updated"
+	HookDeleted   HookActionValue = "deleted"
+	HookUnchanged HookActionValue = "unchanged"
+)
+
+// ReconcileHooksOptions represents the available ReconcileProjectHooks()
+// and ReconcileGroupHooks() options.
+type ReconcileHooksOptions struct {
+	// ReplaceURLs are the URLs of hooks that are replaced by the desired
+	// hook. Hooks with these URLs are deleted.
+	ReplaceURLs []string
+
+	// Remove makes sure no hook with the URL of the spec exists, instead of
+	// making sure exactly one exists.
+	Remove bool
+
+	// UpdateToken forces an update of existing hooks, even if they are up
+	// to date, so the token is set. The API never returns the token, so it
+	// is otherwise only sent when a hook is created or needs an update.
+	UpdateToken bool
+
+	// DryRun reports the actions that would be taken without executing them.
+	DryRun bool
+
+	// Concurrency limits the number of projects or groups that are
+	// reconciled concurrently. Defaults to 1.
+	Concurrency int
+}
+
+// HookReconcileResult represents the result of reconciling a single hook.
+// Either Project or Group is set, depending on the owner of the hook.
+type HookReconcileResult struct {
+	Project interface{}
+	Group   interface{}
+	HookID  int
+	URL     string
+	Action  HookActionValue
+	Err     error
+}
+
+// ReconcileProjectHooks makes sure exactly one hook matching the spec
+// exists for each of the given projects. Missing hooks are created, hooks
+// with different settings are updated, and duplicate hooks and hooks with
+// one of the replaced URLs are deleted. It is safe to call
+// ReconcileProjectHooks multiple times with the same arguments.
+//
+// A result is returned for every hook that was, or with DryRun would have
+// been, acted upon. Failures are recorded in the results, and the first one
+// is also returned as the error.
+func (s *ProjectsService) ReconcileProjectHooks(pids []interface{}, spec *HookSpec, opt *ReconcileHooksOptions, options ...OptionFunc) ([]*HookReconcileResult, error) {
+	var owners []hookOwner
+	for _, pid := range pids {
+		owners = append(owners, &projectHookOwner{client: s.client, pid: pid})
+	}
+	return reconcileHooks(owners, spec, opt, options)
+}
+
+// ReconcileGroupHooks makes sure exactly one hook matching the spec exists
+// for each of the given groups. It works like ReconcileProjectHooks.
+func (s *GroupsService) ReconcileGroupHooks(gids []interface{}, spec *HookSpec, opt *ReconcileHooksOptions, options ...OptionFunc) ([]*HookReconcileResult, error) {
+	var owners []hookOwner
+	for _, gid := range gids {
+		owners = append(owners, &groupHookOwner{client: s.client, gid: gid})
+	}
+	return reconcileHooks(owners, spec, opt, options)
+}
+
+func reconcileHooks(owners []hookOwner, spec *HookSpec, opt *ReconcileHooksOptions, options []OptionFunc) ([]*HookReconcileResult, error) {
+	if opt == nil {
+		opt = &ReconcileHooksOptions{}
+	}
+
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([][]*HookReconcileResult, len(owners))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, owner := range owners {
+		wg.Add(1)
+		go func(i int, owner hookOwner) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = reconcileHook(owner, spec, opt, options...)
+		}(i, owner)
+	}
+	wg.Wait()
+
+	var all []*HookReconcileResult
+	var err error
+	for _, rs := range results {
+		for _, r := range rs {
+			if r.Err != nil && err == nil {
+				err = r.Err
+			}
+			all = append(all, r)
+		}
+	}
+
+	return all, err
+}
+
+func reconcileHook(owner hookOwner, spec *HookSpec, opt *ReconcileHooksOptions, options ...OptionFunc) []*HookReconcileResult {
+	var results []*HookReconcileResult
+	result := func(id int, url string, action HookActionValue, err error) {
+		r := &HookReconcileResult{HookID: id, URL: url, Action: action, Err: err}
+		owner.setOwner(r)
+		results = append(results, r)
+	}
+
+	hooks, err := owner.list(options...)
+	if err != nil {
+		result(0, spec.URL, HookUnchanged, err)
+		return results
+	}
+
+	replaced := make(map[string]bool)
+	for _, u := range opt.ReplaceURLs {
+		replaced[u] = true
+	}
+
+	var current *existingHook
+	for _, h := range hooks {
+		switch {
+		case h.spec.URL == spec.URL && current == nil && !opt.Remove:
+			current = h
+			continue
+		case h.spec.URL == spec.URL, replaced[h.spec.URL]:
+		default:
+			continue
+		}
+
+		// Delete duplicates and replaced hooks.
+		if !opt.DryRun {
+			err = owner.delete(h.id, options...)
+		}
+		result(h.id, h.spec.URL, HookDeleted, err)
+	}
+
+	if opt.Remove {
+		return results
+	}
+
+	if current == nil {
+		id := 0
+		if !opt.DryRun {
+			id, err = owner.add(spec, options...)
+		}
+		result(id, spec.URL, HookCreated, err)
+		return results
+	}
+
+	desired := *spec
+	desired.Token = ""
+	if current.spec == desired && !opt.UpdateToken {
+		result(current.id, spec.URL, HookUnchanged, nil)
+		return results
+	}
+
+	if !opt.DryRun {
+		err = owner.edit(current.id, spec, options...)
+	}
+	result(current.id, spec.URL, HookUpdated, err)
+
+	return results
+}
+
+type existingHook struct {
+	id   int
+	spec HookSpec
+}
+
+// hookOwner abstracts the differences between project and group hooks.
+type hookOwner interface {
+	setOwner(r *HookReconcileResult)
+	list(options ...OptionFunc) ([]*existingHook, error)
+	add(spec *HookSpec, options ...OptionFunc) (int, error)
+	edit(hook int, spec *HookSpec, options ...OptionFunc) error
+	delete(hook int, options ...OptionFunc) error
+}
+
+// hookOptions returns the options to add or edit a hook matching the spec.
+// The add and edit options of project and group hooks all have the same
+// fields, so the result can be converted to each of them.
+func hookOptions(spec *HookSpec) *AddProjectHookOptions {
+	opt := &AddProjectHookOptions{
+		URL:                      String(spec.URL),
+		PushEvents:               Bool(spec.PushEvents),
+		PushEventsBranchFilter:   String(spec.PushEventsBranchFilter),
+		IssuesEvents:             Bool(spec.IssuesEvents),
+		ConfidentialIssuesEvents: Bool(spec.ConfidentialIssuesEvents),
+		MergeRequestsEvents:      Bool(spec.MergeRequestsEvents),
+		TagPushEvents:            Bool(spec.TagPushEvents),
+		NoteEvents:               Bool(spec.NoteEvents),
+		ConfidentialNoteEvents:   Bool(spec.ConfidentialNoteEvents),
+		JobEvents:                Bool(spec.JobEvents),
+		PipelineEvents:           Bool(spec.PipelineEvents),
+		WikiPageEvents:           Bool(spec.WikiPageEvents),
+		EnableSSLVerification:    Bool(spec.EnableSSLVerification),
+	}
+	if spec.Token != "" {
+		opt.Token = String(spec.Token)
+	}
+	return opt
+}
+
+type projectHookOwner struct {
+	client *Client
+	pid    interface{}
+}
+
+func (o *projectHookOwner) setOwner(r *HookReconcileResult) {
+	r.Project = o.pid
+}
+
+func (o *projectHookOwner) list(options ...OptionFunc) ([]*existingHook, error) {
+	var hooks []*existingHook
+
+	opt := &ListProjectHooksOptions{Page: 1, PerPage: 100}
+	for {
+		hs, resp, err := o.client.Projects.ListProjectHooks(o.pid, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range hs {
+			hooks = append(hooks, &existingHook{
+				id: h.ID,
+				spec: HookSpec{
+					URL:                      h.URL,
+					PushEvents:               h.PushEvents,
+					PushEventsBranchFilter:   h.PushEventsBranchFilter,
+					IssuesEvents:             h.IssuesEvents,
+					ConfidentialIssuesEvents: h.ConfidentialIssuesEvents,
+					MergeRequestsEvents:      h.MergeRequestsEvents,
+					TagPushEvents:            h.TagPushEvents,
+					NoteEvents:               h.NoteEvents,
+					ConfidentialNoteEvents:   h.ConfidentialNoteEvents,
+					JobEvents:                h.JobEvents,
+					PipelineEvents:           h.PipelineEvents,
+					WikiPageEvents:           h.WikiPageEvents,
+					EnableSSLVerification:    h.EnableSSLVerification,
+				},
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return hooks, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func (o *projectHookOwner) add(spec *HookSpec, options ...OptionFunc) (int, error) {
+	h, _, err := o.client.Projects.AddProjectHook(o.pid, hookOptions(spec), options...)
+	if err != nil {
+		return 0, err
+	}
+	return h.ID, nil
+}
+
+func (o *projectHookOwner) edit(hook int, spec *HookSpec, options ...OptionFunc) error {
+	_, _, err := o.client.Projects.EditProjectHook(o.pid, hook, (*EditProjectHookOptions)(hookOptions(spec)), options...)
+	return err
+}
+
+func (o *projectHookOwner) delete(hook int, options ...OptionFunc) error {
+	_, err := o.client.Projects.DeleteProjectHook(o.pid, hook, options...)
+	return err
+}
+
+type groupHookOwner struct {
+	client *Client
+	gid    interface{}
+}
+
+func (o *groupHookOwner) setOwner(r *HookReconcileResult) {
+	r.Group = o.gid
+}
+
+func (o *groupHookOwner) list(options ...OptionFunc) ([]*existingHook, error) {
+	var hooks []*existingHook
+
+	opt := &ListGroupHooksOptions{Page: 1, PerPage: 100}
+	for {
+		hs, resp, err := o.client.Groups.ListGroupHooks(o.gid, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range hs {
+			hooks = append(hooks, &existingHook{
+				id: h.ID,
+				spec: HookSpec{
+					URL:                      h.URL,
+					PushEvents:               h.PushEvents,
+					PushEventsBranchFilter:   h.PushEventsBranchFilter,
+					IssuesEvents:             h.IssuesEvents,
+					ConfidentialIssuesEvents: h.ConfidentialIssuesEvents,
+					MergeRequestsEvents:      h.MergeRequestsEvents,
+					TagPushEvents:            h.TagPushEvents,
+					NoteEvents:               h.NoteEvents,
+					ConfidentialNoteEvents:   h.ConfidentialNoteEvents,
+					JobEvents:                h.JobEvents,
+					PipelineEvents:           h.PipelineEvents,
+					WikiPageEvents:           h.WikiPageEvents,
+					EnableSSLVerification:    h.EnableSSLVerification,
+				},
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return hooks, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func (o *groupHookOwner) add(spec *HookSpec, options ...OptionFunc) (int, error) {
+	h, _, err := o.client.Groups.AddGroupHook(o.gid, (*AddGroupHookOptions)(hookOptions(spec)), options...)
+	if err != nil {
+		return 0, err
+	}
+	return h.ID, nil
+}
+
+func (o *groupHookOwner) edit(hook int, spec *HookSpec, options ...OptionFunc) error {
+	_, _, err := o.client.Groups.EditGroupHook(o.gid, hook, (*EditGroupHookOptions)(hookOptions(spec)), options...)
+	return err
+}
+
+func (o *groupHookOwner) delete(hook int, options ...OptionFunc) error {
+	_, err := o.client.Groups.DeleteGroupHook(o.gid, hook, options...)
+	return err
+}
@@ -0,0 +1,72 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestReconcileHooks(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/hooks", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[
+			{"id":1,"url":"http://old.example.com","push_events":true},
+			{"id":2,"url":"http://new.example.com","push_events":false},
+			{"id":3,"url":"http://new.example.com","push_events":true}
+		]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/hooks/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+	})
+	mux.HandleFunc("/api/v4/projects/1/hooks/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		fmt.Fprint(w, `{"id":2}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/hooks/3", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+	})
+	mux.HandleFunc("/api/v4/groups/2/hooks", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `[]`)
+		case "POST":
+			testBody(t, r, `{"url":"http://new.example.com","push_events":true,"push_events_branch_filter":"","issues_events":false,"confidential_issues_events":false,"merge_requests_events":false,"tag_push_events":false,"note_events":false,"confidential_note_events":false,"job_events":false,"pipeline_events":false,"wiki_page_events":false,"enable_ssl_verification":false}`)
+			fmt.Fprint(w, `{"id":4}`)
+		default:
+			t.Errorf("Request method: %s, want GET or POST", r.Method)
+		}
+	})
+
+	spec := &HookSpec{URL: "http://new.example.com", PushEvents: true}
+	opt := &ReconcileHooksOptions{ReplaceURLs: []string{"http://old.example.com"}}
+
+	results, err := client.Projects.ReconcileProjectHooks([]interface{}{1}, spec, opt)
+	if err != nil {
+		t.Fatalf("Projects.ReconcileProjectHooks returned error: %v", err)
+	}
+
+	want := []*HookReconcileResult{
+		{Project: 1, HookID: 1, URL: "http://old.example.com", Action: HookDeleted},
+		{Project: 1, HookID: 3, URL: "http://new.example.com", Action: HookDeleted},
+		{Project: 1, HookID: 2, URL: "http://new.example.com", Action: HookUpdated},
+	}
+	if !reflect.DeepEqual(want, results) {
+		t.Errorf("Projects.ReconcileProjectHooks returned %+v, want %+v", results, want)
+	}
+
+	results, err = client.Groups.ReconcileGroupHooks([]interface{}{2}, spec, opt)
+	if err != nil {
+		t.Fatalf("Groups.ReconcileGroupHooks returned error: %v", err)
+	}
+
+	want = []*HookReconcileResult{
+		{Group: 2, HookID: 4, URL: "http://new.example.com", Action: HookCreated},
+	}
+	if !reflect.DeepEqual(want, results) {
+		t.Errorf("Groups.ReconcileGroupHooks returned %+v, want %+v", results, want)
+	}
+}
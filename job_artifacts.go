@@ -0,0 +1,418 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrArtifactTooLarge is returned when extracting an artifact would exceed
+// the configured size limits.
+var ErrArtifactTooLarge = errors.New("artifact exceeds size limit")
+
+const (
+	// artifactsBlockSize is the number of bytes fetched per range request.
+	artifactsBlockSize = 1 << 20
+
+	// artifactsCachedBlocks is the number of blocks kept in memory.
+	artifactsCachedBlocks = 4
+)
+
+// JobArtifact represents a single artifact file of a job, like the archive
+// itself, its metadata or a report.
+//
+// GitLab API docs: https://docs.gitlab.com/ce/api/jobs.html
+type JobArtifact struct {
+	FileType   string `json:"file_type"`
+	Size       int    `json:"size"`
+	Filename   string `json:"filename"`
+	FileFormat string `json:"file_format"`
+}
+
+// ArtifactsArchive represents the artifacts archive of a job. When the
+// server supports range requests, only the parts of the archive that are
+// needed are downloaded: listing the entries only reads the zip central
+// directory, and extracting a file only reads that file.
+type ArtifactsArchive struct {
+	*zip.Reader
+
+	// Size is the size of the archive in bytes.
+	Size int64
+}
+
+// OpenArtifactsArchive opens the artifacts archive of a job. If the server
+// does not support range requests, the complete archive is downloaded.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/jobs.html#get-job-artifacts
+func (s *JobsService) OpenArtifactsArchive(pid interface{}, jobID int, options ...OptionFunc) (*ArtifactsArchive, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/jobs/%d/artifacts", url.QueryEscape(project), jobID)
+
+	r := &artifactsReaderAt{client: s.client, u: u, options: options}
+
+	// Request the first byte to learn the size of the archive.
+	data, resp, err := r.fetch(0, 0)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	var ra io.ReaderAt = r
+	if resp.StatusCode == http.StatusPartialContent {
+		r.size, err = contentRangeSize(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, resp, err
+		}
+	} else {
+		// The server returned the complete archive.
+		r.size = int64(len(data))
+		ra = bytes.NewReader(data)
+	}
+
+	zr, err := zip.NewReader(ra, r.size)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return &ArtifactsArchive{Reader: zr, Size: r.size}, resp, nil
+}
+
+// Glob returns the regular files in the archive matching any of the given
+// patterns. Patterns use the syntax of path.Match and are matched against
+// the complete path of a file. A pattern ending with a slash matches all
+// files below that directory.
+func (a *ArtifactsArchive) Glob(patterns ...string) ([]*zip.File, error) {
+	var files []*zip.File
+	for _, f := range a.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		ok, err := matchArtifactPath(f.Name, patterns)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// Open opens the file with the given path in the archive.
+func (a *ArtifactsArchive) Open(name string) (io.ReadCloser, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	for _, f := range a.File {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+// ExtractArtifactsOptions represents the available Extract() options.
+type ExtractArtifactsOptions struct {
+	// Patterns selects the files to extract, see Glob. All files are
+	// extracted if no patterns are given.
+	Patterns []string
+
+	// MaxFileSize and MaxTotalSize limit the size of a single extracted
+	// file and of all extracted files together. Zero means no limit.
+	MaxFileSize  int64
+	MaxTotalSize int64
+
+	// Flatten extracts all files directly into the target directory,
+	// dropping the directories of the archive. Extract returns an error
+	// without extracting anything if two files have the same base name.
+	Flatten bool
+}
+
+// Extract extracts files from the archive into the given directory and
+// returns the paths of the extracted files. Entries that would be written
+// outside of the directory are rejected, and symlinks are never extracted.
+// File permissions are taken from the archive, without setuid, setgid and
+// sticky bits and without write permissions for group and others.
+func (a *ArtifactsArchive) Extract(dir string, opt *ExtractArtifactsOptions) ([]string, error) {
+	if opt == nil {
+		opt = &ExtractArtifactsOptions{}
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := a.Glob(opt.Patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.Flatten {
+		seen := make(map[string]string)
+		for _, f := range files {
+			name := path.Base(f.Name)
+			if other, ok := seen[name]; ok {
+				return nil, fmt.Errorf("%s: conflicts with %s when flattened", f.Name, other)
+			}
+			seen[name] = f.Name
+		}
+	}
+
+	var extracted []string
+	var total int64
+
+	for _, f := range files {
+		name := f.Name
+		if opt.Flatten {
+			name = path.Base(name)
+		}
+		target, err := safeExtractPath(dir, name)
+		if err != nil {
+			return extracted, err
+		}
+
+		limit, limited := extractLimit(opt.MaxFileSize, opt.MaxTotalSize, total)
+		if limited && (limit <= 0 || f.UncompressedSize64 > uint64(limit)) {
+			return extracted, fmt.Errorf("%s: %v", f.Name, ErrArtifactTooLarge)
+		}
+
+		n, err := extractArtifact(f, target, limit, limited)
+		if err != nil {
+			return extracted, fmt.Errorf("%s: %v", f.Name, err)
+		}
+		total += n
+		extracted = append(extracted, target)
+	}
+
+	return extracted, nil
+}
+
+// extractLimit returns the number of bytes a single file may still use,
+// given the file and total size limits and the number of bytes extracted
+// so far. A zero limit means no limit; limited reports whether any limit
+// applies at all.
+func extractLimit(maxFileSize, maxTotalSize, total int64) (limit int64, limited bool) {
+	limit, limited = maxFileSize, maxFileSize > 0
+	if maxTotalSize > 0 {
+		if remaining := maxTotalSize - total; !limited || remaining < limit {
+			limit, limited = remaining, true
+		}
+	}
+	return limit, limited
+}
+
+func extractArtifact(f *zip.File, target string, limit int64, limited bool) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	return writeExtractedFile(target, f.Mode(), rc, limit, limited, ErrArtifactTooLarge)
+}
+
+// writeExtractedFile writes the contents of r to target. If limited is
+// true, tooLarge is returned when r holds more than limit bytes. File
+// permissions are taken from mode, without setuid, setgid and sticky bits
+// and without write permissions for group and others.
+func writeExtractedFile(target string, mode os.FileMode, r io.Reader, limit int64, limited bool, tooLarge error) (int64, error) {
+	perm := mode.Perm()&^0022 | 0600
+	w, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, err
+	}
+
+	// The sizes in the archive can't be trusted, so enforce the limit
+	// while copying as well.
+	if limited {
+		r = io.LimitReader(r, limit+1)
+	}
+
+	n, err := io.Copy(w, r)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && limited && n > limit {
+		err = tooLarge
+	}
+	if err != nil {
+		os.Remove(target)
+		return 0, err
+	}
+
+	// Apply the permissions even if the file already existed.
+	return n, os.Chmod(target, perm)
+}
+
+// safeExtractPath returns the path to extract the archive entry with the
+// given name to, making sure it is located inside dir.
+func safeExtractPath(dir, name string) (string, error) {
+	if path.IsAbs(name) || filepath.IsAbs(name) || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("%s: illegal path in archive", name)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if !strings.HasPrefix(target, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: illegal path in archive", name)
+	}
+
+	return target, nil
+}
+
+func matchArtifactPath(name string, patterns []string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "/") {
+			if strings.HasPrefix(name, p) {
+				return true, nil
+			}
+			continue
+		}
+		ok, err := path.Match(p, name)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// artifactsReaderAt implements io.ReaderAt on top of range requests, keeping
+// a few recently read blocks in memory.
+type artifactsReaderAt struct {
+	client  *Client
+	u       string
+	options []OptionFunc
+	size    int64
+
+	mu     sync.Mutex
+	blocks map[int64][]byte
+	order  []int64
+}
+
+func (r *artifactsReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	n := 0
+	for n < len(p) && off < r.size {
+		block, err := r.block(off / artifactsBlockSize)
+		if err != nil {
+			return n, err
+		}
+		i := off % artifactsBlockSize
+		if i >= int64(len(block)) {
+			return n, io.ErrUnexpectedEOF
+		}
+		c := copy(p[n:], block[i:])
+		n += c
+		off += int64(c)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *artifactsReaderAt) block(i int64) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b, ok := r.blocks[i]; ok {
+		return b, nil
+	}
+
+	start := i * artifactsBlockSize
+	end := start + artifactsBlockSize - 1
+	if end >= r.size {
+		end = r.size - 1
+	}
+
+	b, resp, err := r.fetch(start, end)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("server stopped supporting range requests")
+	}
+
+	if r.blocks == nil {
+		r.blocks = make(map[int64][]byte)
+	}
+	if len(r.order) == artifactsCachedBlocks {
+		delete(r.blocks, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.blocks[i] = b
+	r.order = append(r.order, i)
+
+	return b, nil
+}
+
+func (r *artifactsReaderAt) fetch(start, end int64) ([]byte, *Response, error) {
+	req, err := r.client.NewRequest("GET", r.u, nil, r.options)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	buf := new(bytes.Buffer)
+	resp, err := r.client.Do(req, buf)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return buf.Bytes(), resp, nil
+}
+
+// contentRangeSize returns the complete size from a Content-Range header
+// like "bytes 0-0/1234".
+func contentRangeSize(header string) (int64, error) {
+	i := strings.LastIndex(header, "/")
+	if i < 0 {
+		return 0, fmt.Errorf("invalid Content-Range header %q", header)
+	}
+	size, err := strconv.ParseInt(header[i+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Content-Range header %q", header)
+	}
+	return size, nil
+}
@@ -0,0 +1,210 @@
+package gitlab
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testArtifactsZip(t *testing.T, files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		h := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		h.SetMode(0755)
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenArtifactsArchive(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	archive := testArtifactsZip(t, map[string]string{
+		"bin/linux/tool":   "linux binary",
+		"bin/windows/tool": "windows binary",
+		"report.xml":       "<testsuites/>",
+	})
+
+	var ranges []string
+	mux.HandleFunc("/api/v4/projects/1/jobs/2/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "artifacts.zip", time.Time{}, bytes.NewReader(archive))
+	})
+
+	a, _, err := client.Jobs.OpenArtifactsArchive(1, 2)
+	if err != nil {
+		t.Fatalf("Jobs.OpenArtifactsArchive returned error: %v", err)
+	}
+	if a.Size != int64(len(archive)) || len(a.File) != 3 {
+		t.Errorf("Jobs.OpenArtifactsArchive returned size %d and %d files", a.Size, len(a.File))
+	}
+	if len(ranges) == 0 || ranges[0] != "bytes=0-0" {
+		t.Errorf("Jobs.OpenArtifactsArchive sent ranges %v", ranges)
+	}
+
+	files, err := a.Glob("bin/*/tool")
+	if err != nil {
+		t.Fatalf("ArtifactsArchive.Glob returned error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("ArtifactsArchive.Glob returned %d files, want 2", len(files))
+	}
+
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	extracted, err := a.Extract(dir, &ExtractArtifactsOptions{Patterns: []string{"bin/linux/"}})
+	if err != nil {
+		t.Fatalf("ArtifactsArchive.Extract returned error: %v", err)
+	}
+	want := []string{filepath.Join(dir, "bin", "linux", "tool")}
+	if !reflect.DeepEqual(want, extracted) {
+		t.Errorf("ArtifactsArchive.Extract returned %v, want %v", extracted, want)
+	}
+
+	content, err := ioutil.ReadFile(want[0])
+	if err != nil || string(content) != "linux binary" {
+		t.Errorf("ArtifactsArchive.Extract wrote %q, %v", content, err)
+	}
+	if fi, err := os.Stat(want[0]); err != nil || fi.Mode().Perm() != 0755 {
+		t.Errorf("ArtifactsArchive.Extract created file with mode %v, %v", fi.Mode(), err)
+	}
+
+	_, err = a.Extract(dir, &ExtractArtifactsOptions{Patterns: []string{"report.xml"}, MaxFileSize: 5})
+	if err == nil || !strings.Contains(err.Error(), ErrArtifactTooLarge.Error()) {
+		t.Errorf("ArtifactsArchive.Extract returned error %v, want %v", err, ErrArtifactTooLarge)
+	}
+}
+
+func TestArtifactsArchiveExtractZipSlip(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	archive := testArtifactsZip(t, map[string]string{"../evil": "evil"})
+
+	mux.HandleFunc("/api/v4/projects/1/jobs/2/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		// Ignore the range header, like servers without range support.
+		w.Write(archive)
+	})
+
+	a, _, err := client.Jobs.OpenArtifactsArchive(1, 2)
+	if err != nil {
+		t.Fatalf("Jobs.OpenArtifactsArchive returned error: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := a.Extract(dir, nil); err == nil {
+		t.Error("ArtifactsArchive.Extract returned no error for a path outside of the target directory")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "evil")); !os.IsNotExist(err) {
+		t.Error("ArtifactsArchive.Extract wrote a file outside of the target directory")
+	}
+}
+
+func TestArtifactsArchiveExtractFlattenConflict(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	archive := testArtifactsZip(t, map[string]string{
+		"bin/linux/tool":   "linux binary",
+		"bin/windows/tool": "windows binary",
+	})
+
+	mux.HandleFunc("/api/v4/projects/1/jobs/2/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+
+	a, _, err := client.Jobs.OpenArtifactsArchive(1, 2)
+	if err != nil {
+		t.Fatalf("Jobs.OpenArtifactsArchive returned error: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files, err := a.Extract(dir, &ExtractArtifactsOptions{Flatten: true})
+	if err == nil || !strings.Contains(err.Error(), "conflicts with") {
+		t.Errorf("ArtifactsArchive.Extract returned error %v, want a conflict", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("ArtifactsArchive.Extract extracted %v, want nothing", files)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tool")); !os.IsNotExist(err) {
+		t.Error("ArtifactsArchive.Extract wrote a file despite the conflict")
+	}
+}
+
+func TestArtifactsArchiveExtractMaxTotalSize(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	archive := testArtifactsZip(t, map[string]string{
+		"a": "0123456789",
+		"b": "0123456789",
+		"c": "0123456789",
+	})
+
+	mux.HandleFunc("/api/v4/projects/1/jobs/2/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+
+	a, _, err := client.Jobs.OpenArtifactsArchive(1, 2)
+	if err != nil {
+		t.Fatalf("Jobs.OpenArtifactsArchive returned error: %v", err)
+	}
+
+	for _, total := range []int64{10, 20} {
+		dir, err := ioutil.TempDir("", "artifacts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		files, err := a.Extract(dir, &ExtractArtifactsOptions{MaxTotalSize: total})
+		if err == nil || !strings.Contains(err.Error(), ErrArtifactTooLarge.Error()) {
+			t.Errorf("ArtifactsArchive.Extract returned error %v, want %v", err, ErrArtifactTooLarge)
+		}
+		if int64(len(files))*10 != total {
+			t.Errorf("ArtifactsArchive.Extract extracted %d files with a total limit of %d bytes", len(files), total)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files, err := a.Extract(dir, &ExtractArtifactsOptions{MaxTotalSize: 30})
+	if err != nil || len(files) != 3 {
+		t.Errorf("ArtifactsArchive.Extract returned %v, %v, want 3 files", files, err)
+	}
+}
@@ -0,0 +1,501 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// JobFailureRule represents a class of job failures. A failed job matches
+// the rule if its failure reason is one of FailureReasons, or if the end of
+// its trace matches one of TracePatterns.
+type JobFailureRule struct {
+	Name           string
+	FailureReasons []string
+	TracePatterns  []*regexp.Regexp
+
+	// Retry marks the failures of this class as transient, so the jobs
+	// are retried.
+	Retry bool
+}
+
+// DefaultJobFailureRules returns rules for common transient failures:
+// runner system failures, network problems and jobs killed because they
+// ran out of memory.
+func DefaultJobFailureRules() []*JobFailureRule {
+	return []*JobFailureRule{
+		{
+			Name: "runner_system_failure",
+			FailureReasons: []string{
+				"runner_system_failure",
+				"stuck_or_timeout_failure",
+				"scheduler_failure",
+				"api_failure",
+				"data_integrity_failure",
+			},
+			TracePatterns: []*regexp.Regexp{
+				regexp.MustCompile(`ERROR: Job failed \(system failure\)`),
+				regexp.MustCompile(`ERROR: Job failed: execution took longer than`),
+			},
+			Retry: true,
+		},
+		{
+			Name: "network",
+			TracePatterns: []*regexp.Regexp{
+				regexp.MustCompile(`(?i)connection (reset by peer|refused|timed out)`),
+				regexp.MustCompile(`(?i)i/o timeout`),
+				regexp.MustCompile(`(?i)TLS handshake timeout`),
+				regexp.MustCompile(`(?i)could not resolve host`),
+				regexp.MustCompile(`(?i)temporary failure in name resolution`),
+				regexp.MustCompile(`(?i)(502 Bad Gateway|503 Service Unavailable|504 Gateway Time-?out)`),
+			},
+			Retry: true,
+		},
+		{
+			Name: "out_of_memory",
+			TracePatterns: []*regexp.Regexp{
+				regexp.MustCompile(`(?i)out of memory`),
+				regexp.MustCompile(`OOMKilled`),
+				regexp.MustCompile(`(?i)exit code 137`),
+				regexp.MustCompile(`(?i)cannot allocate memory`),
+			},
+			Retry: true,
+		},
+	}
+}
+
+// JobRetryDecision represents the decision of a JobRetryPolicy for a single
+// failed job.
+type JobRetryDecision struct {
+	Job *Job
+
+	// Rule is the matching rule, or nil if the failure wasn't classified.
+	Rule *JobFailureRule
+
+	// Attempts is the number of times the job ran in the pipeline.
+	Attempts int
+
+	// Retried reports whether the job was retried, and RetriedJob is the
+	// new job. When DryRun is set, Retried reports whether the job would
+	// have been retried.
+	Retried    bool
+	RetriedJob *Job
+
+	// Reason explains the decision.
+	Reason string
+}
+
+// JobFlakinessStats represents the flakiness statistics of all jobs with
+// the same name.
+type JobFlakinessStats struct {
+	Name string
+
+	// Runs and Failures count all attempts seen, including retried ones.
+	Runs     int
+	Failures int
+
+	// Retries counts the retries made by the policy.
+	Retries int
+
+	// FlakyPipelines counts the pipelines in which the job failed and
+	// succeeded on a later attempt.
+	FlakyPipelines int
+
+	// Classes counts the failures by the name of the matching rule.
+	// Unclassified failures are counted as "unknown".
+	Classes map[string]int
+}
+
+// JobRetryPolicy classifies failed jobs and retries transient failures.
+// Statistics are collected across all pipelines the policy is applied to.
+type JobRetryPolicy struct {
+	client *Client
+
+	// Rules are evaluated in order, the first matching rule is used.
+	Rules []*JobFailureRule
+
+	// MaxRetries is the number of times a job is retried within a single
+	// pipeline. It defaults to 2 and can be set per job name with
+	// JobMaxRetries.
+	MaxRetries    int
+	JobMaxRetries map[string]int
+
+	// TraceTailSize is the number of bytes at the end of the trace that
+	// are matched against the trace patterns. Defaults to 64 KiB.
+	TraceTailSize int
+
+	// DryRun only reports the decisions without retrying any jobs.
+	DryRun bool
+
+	mu    sync.Mutex
+	stats map[string]*JobFlakinessStats
+
+	// pipelines holds the counted jobs of the pipelines that aren't
+	// finished yet. Once all jobs of a pipeline reached a terminal state
+	// and none was retried, its entry is evicted and its ID is added to
+	// finished, which remembers the last maxFinishedPipelines pipelines so
+	// their jobs aren't counted again.
+	pipelines map[int]*jobRetryPipeline
+	finished  []int
+}
+
+// maxFinishedPipelines is the number of finished pipelines remembered by a
+// JobRetryPolicy.
+const maxFinishedPipelines = 1024
+
+// jobRetryPipeline represents the jobs of a pipeline that were counted in
+// the statistics.
+type jobRetryPipeline struct {
+	seen  map[int]bool
+	flaky map[string]bool
+}
+
+// NewJobRetryPolicy returns a new JobRetryPolicy using the default rules.
+func NewJobRetryPolicy(client *Client) *JobRetryPolicy {
+	return &JobRetryPolicy{
+		client:    client,
+		Rules:     DefaultJobFailureRules(),
+		stats:     make(map[string]*JobFlakinessStats),
+		pipelines: make(map[int]*jobRetryPipeline),
+	}
+}
+
+// ClassifyTrace returns the first rule matching the failure reason of the
+// job or the given trace, or nil if no rule matches.
+func (p *JobRetryPolicy) ClassifyTrace(job *Job, trace string) *JobFailureRule {
+	for _, r := range p.Rules {
+		for _, reason := range r.FailureReasons {
+			if job.FailureReason == reason {
+				return r
+			}
+		}
+	}
+
+	if trace == "" {
+		return nil
+	}
+	trace = StripANSI(trace)
+
+	for _, r := range p.Rules {
+		for _, re := range r.TracePatterns {
+			if re.MatchString(trace) {
+				return r
+			}
+		}
+	}
+
+	return nil
+}
+
+// Classify returns the first rule matching the given failed job. The trace
+// of the job is only fetched if its failure reason doesn't match a rule.
+func (p *JobRetryPolicy) Classify(pid interface{}, job *Job, options ...OptionFunc) (*JobFailureRule, error) {
+	return p.classify(pid, job, nil, options)
+}
+
+// classify works like Classify, and caches the trace tails in traces if it
+// isn't nil.
+func (p *JobRetryPolicy) classify(pid interface{}, job *Job, traces map[int]string, options []OptionFunc) (*JobFailureRule, error) {
+	if r := p.ClassifyTrace(job, ""); r != nil {
+		return r, nil
+	}
+
+	trace, ok := traces[job.ID]
+	if !ok {
+		var err error
+		if trace, err = p.traceTail(pid, job.ID, options); err != nil {
+			return nil, err
+		}
+		if traces != nil {
+			traces[job.ID] = trace
+		}
+	}
+
+	return p.ClassifyTrace(job, trace), nil
+}
+
+func (p *JobRetryPolicy) traceTail(pid interface{}, jobID int, options []OptionFunc) (string, error) {
+	r, _, err := p.client.Jobs.GetTraceFile(pid, jobID, options...)
+	if err != nil {
+		return "", err
+	}
+	data, err := readAllBytes(r)
+	if err != nil {
+		return "", err
+	}
+
+	size := p.TraceTailSize
+	if size <= 0 {
+		size = 64 * 1024
+	}
+	if len(data) > size {
+		data = data[len(data)-size:]
+	}
+
+	return string(data), nil
+}
+
+// Apply classifies the failed jobs of a pipeline, retries the jobs with a
+// transient failure that didn't reach their retry limit, and updates the
+// flakiness statistics. Only the latest attempt of every job is considered.
+// Jobs that are allowed to fail are ignored.
+func (p *JobRetryPolicy) Apply(pid interface{}, pipeline int, options ...OptionFunc) ([]*JobRetryDecision, error) {
+	jobs, err := p.listAttempts(pid, pipeline, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the attempts by name, oldest first.
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	var names []string
+	attempts := make(map[string][]*Job)
+	for _, j := range jobs {
+		if j.AllowFailure {
+			continue
+		}
+		if _, ok := attempts[j.Name]; !ok {
+			names = append(names, j.Name)
+		}
+		attempts[j.Name] = append(attempts[j.Name], j)
+	}
+
+	// Traces are only cached while the policy is applied, as only the
+	// attempts that weren't counted before are classified.
+	traces := make(map[int]string)
+	finished := true
+
+	var decisions []*JobRetryDecision
+	for _, name := range names {
+		runs := attempts[name]
+		latest := runs[len(runs)-1]
+		if !IsTerminalPipelineStatus(latest.Status) {
+			finished = false
+		}
+
+		classes := make(map[int]string)
+		for _, j := range runs {
+			if j.Status != "failed" || p.counted(pipeline, j.ID) {
+				continue
+			}
+			r, err := p.classify(pid, j, traces, options)
+			if err != nil {
+				return decisions, err
+			}
+			classes[j.ID] = "unknown"
+			if r != nil {
+				classes[j.ID] = r.Name
+			}
+		}
+		p.record(pipeline, name, runs, classes)
+
+		if latest.Status != "failed" {
+			continue
+		}
+
+		d, err := p.decide(pid, latest, len(runs), traces, options)
+		if err != nil {
+			return decisions, err
+		}
+		if d.RetriedJob != nil {
+			finished = false
+		}
+		decisions = append(decisions, d)
+	}
+
+	if finished {
+		p.finish(pipeline)
+	}
+
+	return decisions, nil
+}
+
+func (p *JobRetryPolicy) decide(pid interface{}, job *Job, attempts int, traces map[int]string, options []OptionFunc) (*JobRetryDecision, error) {
+	d := &JobRetryDecision{Job: job, Attempts: attempts}
+
+	r, err := p.classify(pid, job, traces, options)
+	if err != nil {
+		return nil, err
+	}
+	d.Rule = r
+
+	max := p.MaxRetries
+	if max <= 0 {
+		max = 2
+	}
+	if m, ok := p.JobMaxRetries[job.Name]; ok {
+		max = m
+	}
+
+	switch {
+	case r == nil:
+		d.Reason = "failure not classified"
+		return d, nil
+	case !r.Retry:
+		d.Reason = fmt.Sprintf("%s failures are not retried", r.Name)
+		return d, nil
+	case attempts > max:
+		d.Reason = fmt.Sprintf("retry limit of %d reached", max)
+		return d, nil
+	}
+
+	d.Retried = true
+	d.Reason = fmt.Sprintf("retrying %s failure, attempt %d of %d", r.Name, attempts+1, max+1)
+	if p.DryRun {
+		return d, nil
+	}
+
+	d.RetriedJob, _, err = p.client.Jobs.RetryJob(pid, job.ID, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.statsFor(job.Name).Retries++
+	p.mu.Unlock()
+
+	return d, nil
+}
+
+func (p *JobRetryPolicy) listAttempts(pid interface{}, pipeline int, options []OptionFunc) ([]*Job, error) {
+	var jobs []*Job
+
+	opt := &ListJobsOptions{
+		ListOptions:    ListOptions{Page: 1, PerPage: 100},
+		IncludeRetried: Bool(true),
+	}
+	for {
+		js, resp, err := p.client.Jobs.ListPipelineJobs(pid, pipeline, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, js...)
+
+		if resp.NextPage == 0 {
+			return jobs, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// counted reports whether the given job of a pipeline was already counted
+// in the statistics. All jobs of finished pipelines were counted.
+func (p *JobRetryPolicy) counted(pipeline, job int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isFinished(pipeline) {
+		return true
+	}
+	pl, ok := p.pipelines[pipeline]
+	return ok && pl.seen[job]
+}
+
+func (p *JobRetryPolicy) isFinished(pipeline int) bool {
+	for _, id := range p.finished {
+		if id == pipeline {
+			return true
+		}
+	}
+	return false
+}
+
+// finish evicts the state of a pipeline whose jobs all reached a terminal
+// state.
+func (p *JobRetryPolicy) finish(pipeline int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.pipelines, pipeline)
+	if p.isFinished(pipeline) {
+		return
+	}
+	if len(p.finished) >= maxFinishedPipelines {
+		p.finished = p.finished[1:]
+	}
+	p.finished = append(p.finished, pipeline)
+}
+
+// record updates the statistics with all finished attempts that weren't
+// seen before.
+func (p *JobRetryPolicy) record(pipeline int, name string, runs []*Job, classes map[int]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isFinished(pipeline) {
+		return
+	}
+	pl, ok := p.pipelines[pipeline]
+	if !ok {
+		pl = &jobRetryPipeline{seen: make(map[int]bool), flaky: make(map[string]bool)}
+		p.pipelines[pipeline] = pl
+	}
+
+	s := p.statsFor(name)
+	failed := false
+	for _, j := range runs {
+		if j.Status == "failed" {
+			failed = true
+		}
+		if !IsTerminalPipelineStatus(j.Status) || pl.seen[j.ID] {
+			continue
+		}
+		pl.seen[j.ID] = true
+
+		s.Runs++
+		if class, ok := classes[j.ID]; ok {
+			s.Failures++
+			s.Classes[class]++
+		}
+	}
+
+	if failed && runs[len(runs)-1].Status == "success" && !pl.flaky[name] {
+		pl.flaky[name] = true
+		s.FlakyPipelines++
+	}
+}
+
+func (p *JobRetryPolicy) statsFor(name string) *JobFlakinessStats {
+	s, ok := p.stats[name]
+	if !ok {
+		s = &JobFlakinessStats{Name: name, Classes: make(map[string]int)}
+		p.stats[name] = s
+	}
+	return s
+}
+
+// Stats returns a copy of the flakiness statistics of all jobs, ordered by
+// job name.
+func (p *JobRetryPolicy) Stats() []*JobFlakinessStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]*JobFlakinessStats, 0, len(p.stats))
+	for _, s := range p.stats {
+		c := *s
+		c.Classes = make(map[string]int, len(s.Classes))
+		for k, v := range s.Classes {
+			c.Classes[k] = v
+		}
+		stats = append(stats, &c)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+
+	return stats
+}
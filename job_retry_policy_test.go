@@ -0,0 +1,103 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestJobRetryPolicy(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/2/jobs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if r.URL.Query().Get("include_retried") != "true" {
+			t.Errorf("Request query is %s", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `[
+			{"id":10,"name":"lint","status":"failed"},
+			{"id":11,"name":"unit","status":"failed","failure_reason":"script_failure"},
+			{"id":12,"name":"unit","status":"success"},
+			{"id":13,"name":"e2e","status":"failed","failure_reason":"runner_system_failure"},
+			{"id":14,"name":"deploy","status":"failed","failure_reason":"script_failure"},
+			{"id":15,"name":"deploy","status":"failed","failure_reason":"script_failure"},
+			{"id":16,"name":"deploy","status":"failed","failure_reason":"script_failure"},
+			{"id":18,"name":"docs","status":"failed","failure_reason":"runner_system_failure","allow_failure":true}
+		]`)
+	})
+	traces := map[int]string{
+		10: "$ golangci-lint run\nmain.go:1: unused variable\n",
+		11: "dial tcp 10.0.0.1:443: i/o timeout\n",
+		14: "\x1b[31mconnection reset by peer\x1b[0m\n",
+		15: "connection reset by peer\n",
+		16: "connection reset by peer\n",
+	}
+	for id, trace := range traces {
+		trace := trace
+		mux.HandleFunc(fmt.Sprintf("/api/v4/projects/1/jobs/%d/trace", id), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, trace)
+		})
+	}
+	retried := 0
+	mux.HandleFunc("/api/v4/projects/1/jobs/13/retry", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		retried++
+		fmt.Fprint(w, `{"id":17,"name":"e2e","status":"pending"}`)
+	})
+
+	p := NewJobRetryPolicy(client)
+
+	decisions, err := p.Apply(1, 2)
+	if err != nil {
+		t.Fatalf("JobRetryPolicy.Apply returned error: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, d := range decisions {
+		rule := "unknown"
+		if d.Rule != nil {
+			rule = d.Rule.Name
+		}
+		got[d.Job.Name] = fmt.Sprintf("%s:%v", rule, d.Retried)
+	}
+	want := map[string]string{
+		"lint":   "unknown:false",
+		"e2e":    "runner_system_failure:true",
+		"deploy": "network:false",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("JobRetryPolicy.Apply returned %v, want %v", got, want)
+	}
+	if retried != 1 {
+		t.Errorf("JobRetryPolicy.Apply retried %d jobs, want 1", retried)
+	}
+
+	// Applying the policy again must not count the same jobs twice. As
+	// no job is retried anymore, the pipeline is finished.
+	p.DryRun = true
+	for i := 0; i < 2; i++ {
+		if _, err := p.Apply(1, 2); err != nil {
+			t.Fatalf("JobRetryPolicy.Apply returned error: %v", err)
+		}
+	}
+	if len(p.pipelines) != 0 || !reflect.DeepEqual(p.finished, []int{2}) {
+		t.Errorf("JobRetryPolicy kept %d pipelines and finished %v, want only finished pipeline 2", len(p.pipelines), p.finished)
+	}
+
+	stats := p.Stats()
+	if len(stats) != 4 {
+		t.Fatalf("JobRetryPolicy.Stats returned %d entries, want 4", len(stats))
+	}
+	deploy, unit := stats[0], stats[3]
+	if deploy.Name != "deploy" || deploy.Runs != 3 || deploy.Failures != 3 || deploy.Classes["network"] != 3 {
+		t.Errorf("JobRetryPolicy.Stats returned %+v", deploy)
+	}
+	if unit.Name != "unit" || unit.Runs != 2 || unit.FlakyPipelines != 1 || unit.Classes["network"] != 1 {
+		t.Errorf("JobRetryPolicy.Stats returned %+v", unit)
+	}
+	if e2e := stats[1]; e2e.Retries != 1 {
+		t.Errorf("JobRetryPolicy.Stats returned %+v", e2e)
+	}
+}
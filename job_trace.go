@@ -0,0 +1,330 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ansiEscapeRegexp matches ANSI escape sequences, as used for colors
+	// and to clear lines.
+	ansiEscapeRegexp = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+	// traceSectionRegexp matches the markers GitLab uses to delimit
+	// collapsible sections in job traces, e.g.
+	// "section_start:1560896352:build_script[collapsed=true]\r\x1b[0K".
+	traceSectionRegexp = regexp.MustCompile(`section_(start|end):(\d+):([^\r\n\[]+)(?:\[[^\]\r\n]*\])?\r\x1b\[0K`)
+)
+
+// StripANSI removes all ANSI escape sequences from the given text.
+func StripANSI(s string) string {
+	return ansiEscapeRegexp.ReplaceAllString(s, "")
+}
+
+// StripTraceSections removes all section markers from the given job trace
+// text.
+func StripTraceSections(s string) string {
+	return traceSectionRegexp.ReplaceAllString(s, "")
+}
+
+// GetTraceFileFrom gets the part of the trace of a specific job starting at
+// the given byte offset, using an HTTP range request. An empty reader is
+// returned if there is no data after the offset.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/jobs.html#get-a-trace-file
+func (s *JobsService) GetTraceFileFrom(pid interface{}, jobID int, offset int64, options ...OptionFunc) (io.Reader, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/jobs/%d/trace", url.QueryEscape(project), jobID)
+
+	req, err := s.client.NewRequest("GET", u, nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	traceBuf := new(bytes.Buffer)
+	resp, err := s.client.Do(req, traceBuf)
+	if err != nil {
+		// The offset is at or past the end of the trace.
+		if resp != nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return new(bytes.Buffer), resp, nil
+		}
+		return nil, resp, err
+	}
+
+	// Servers that don't support range requests return the full trace.
+	if offset > 0 && resp.StatusCode != http.StatusPartialContent {
+		if int64(traceBuf.Len()) <= offset {
+			return new(bytes.Buffer), resp, nil
+		}
+		traceBuf.Next(int(offset))
+	}
+
+	return traceBuf, resp, err
+}
+
+// FollowTraceOptions represents the available FollowTrace() options.
+type FollowTraceOptions struct {
+	// Interval between two polls for new trace data. Defaults to 2 seconds.
+	Interval time.Duration
+
+	// StripANSI removes ANSI escape sequences, like colors, from the trace.
+	StripANSI bool
+
+	// StripSections removes the section_start and section_end markers from
+	// the trace.
+	StripSections bool
+}
+
+// FollowTrace returns a reader that follows the trace of a running job.
+// Only new bytes are fetched on every poll. Reads block until new data is
+// available, and the reader returns io.EOF once the job has finished and
+// the complete trace was read. The reader must be closed to stop polling.
+//
+// When stripping is enabled, the trace is processed line by line, so a
+// line is only returned once it is complete.
+func (s *JobsService) FollowTrace(ctx context.Context, pid interface{}, jobID int, opt *FollowTraceOptions, options ...OptionFunc) io.ReadCloser {
+	if opt == nil {
+		opt = &FollowTraceOptions{}
+	}
+
+	interval := opt.Interval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer cancel()
+		pw.CloseWithError(s.followTrace(ctx, pid, jobID, interval, opt, pw, options...))
+	}()
+
+	return &traceFollower{PipeReader: pr, cancel: cancel}
+}
+
+type traceFollower struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (f *traceFollower) Close() error {
+	f.cancel()
+	return f.PipeReader.Close()
+}
+
+func (s *JobsService) followTrace(ctx context.Context, pid interface{}, jobID int, interval time.Duration, opt *FollowTraceOptions, w io.Writer, options ...OptionFunc) error {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	strip := opt.StripANSI || opt.StripSections
+
+	var offset int64
+	var partial []byte
+
+	for {
+		// Get the status before the trace, so that once the job is
+		// finished the trace fetched afterwards is known to be complete.
+		job, _, err := s.GetJob(pid, jobID, options...)
+		if err != nil {
+			return err
+		}
+		finished := IsTerminalPipelineStatus(job.Status)
+
+		trace, _, err := s.GetTraceFileFrom(pid, jobID, offset, options...)
+		if err != nil {
+			return err
+		}
+		data, err := readAllBytes(trace)
+		if err != nil {
+			return err
+		}
+		offset += int64(len(data))
+
+		if strip {
+			partial = append(partial, data...)
+			data = nil
+			if i := bytes.LastIndexByte(partial, '\n'); i >= 0 {
+				data = []byte(stripTrace(string(partial[:i+1]), opt))
+				partial = append([]byte(nil), partial[i+1:]...)
+			}
+			if finished {
+				data = append(data, stripTrace(string(partial), opt)...)
+			}
+		}
+
+		if len(data) > 0 {
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+
+		if finished {
+			return io.EOF
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func stripTrace(s string, opt *FollowTraceOptions) string {
+	if opt.StripSections {
+		s = StripTraceSections(s)
+	}
+	if opt.StripANSI {
+		s = StripANSI(s)
+	}
+	return s
+}
+
+func readAllBytes(r io.Reader) ([]byte, error) {
+	if b, ok := r.(*bytes.Buffer); ok {
+		return b.Bytes(), nil
+	}
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(r)
+	return buf.Bytes(), err
+}
+
+// TraceSection represents a collapsible section of a job trace. Content
+// outside of any named section is returned in sections without a name.
+type TraceSection struct {
+	Name     string
+	Header   string
+	Start    *time.Time
+	End      *time.Time
+	Duration time.Duration
+	Lines    []string
+	Sections []*TraceSection
+}
+
+// ParseTraceSections splits a job trace into its collapsible sections.
+// Nested sections are returned as children of the enclosing section. All
+// ANSI escape sequences are removed from the returned lines and headers.
+func ParseTraceSections(r io.Reader) ([]*TraceSection, error) {
+	root := &TraceSection{}
+	stack := []*TraceSection{root}
+
+	// current returns the section new lines are added to, creating an
+	// unnamed section at the top level if needed.
+	current := func() *TraceSection {
+		top := stack[len(stack)-1]
+		if top != root {
+			return top
+		}
+		if n := len(root.Sections); n > 0 && root.Sections[n-1].Name == "" {
+			return root.Sections[n-1]
+		}
+		s := &TraceSection{}
+		root.Sections = append(root.Sections, s)
+		return s
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		text := ""
+		markers := traceSectionRegexp.FindAllStringSubmatchIndex(line, -1)
+		last := 0
+		startedHere := false
+
+		for _, m := range markers {
+			text += line[last:m[0]]
+			last = m[1]
+
+			kind := line[m[2]:m[3]]
+			ts, _ := strconv.ParseInt(line[m[4]:m[5]], 10, 64)
+			at := time.Unix(ts, 0).UTC()
+			name := line[m[6]:m[7]]
+
+			if kind == "start" {
+				if strings.TrimSpace(text) != "" {
+					cur := current()
+					cur.Lines = append(cur.Lines, StripANSI(text))
+				}
+				text = ""
+
+				s := &TraceSection{Name: name, Start: &at}
+				parent := stack[len(stack)-1]
+				parent.Sections = append(parent.Sections, s)
+				stack = append(stack, s)
+				startedHere = true
+				continue
+			}
+
+			// Close the matching section and any unclosed nested ones.
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].Name != name {
+					continue
+				}
+				if strings.TrimSpace(text) != "" {
+					stack[len(stack)-1].Lines = append(stack[len(stack)-1].Lines, StripANSI(text))
+				}
+				text = ""
+
+				s := stack[i]
+				s.End = &at
+				s.Duration = at.Sub(*s.Start)
+				stack = stack[:i]
+				break
+			}
+		}
+		text += line[last:]
+
+		if startedHere && len(stack) > 1 && stack[len(stack)-1].Header == "" {
+			// The text following a start marker is the section header.
+			stack[len(stack)-1].Header = StripANSI(text)
+			continue
+		}
+
+		if len(markers) > 0 && strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		cur := current()
+		cur.Lines = append(cur.Lines, StripANSI(text))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return root.Sections, nil
+}
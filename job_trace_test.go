@@ -0,0 +1,94 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFollowTrace(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	trace := "\x1b[32;1mRunning\x1b[0;m\nsection_start:1:build\r\x1b[0Kbuilding\nsection_end:2:build\r\x1b[0K\ndone\n"
+	chunks := []int{12, 40, len(trace)}
+	polls := 0
+
+	mux.HandleFunc("/api/v4/projects/1/jobs/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		status := "running"
+		if polls == len(chunks)-1 {
+			status = "success"
+		}
+		fmt.Fprintf(w, `{"id":2,"status":%q}`, status)
+	})
+	mux.HandleFunc("/api/v4/projects/1/jobs/2/trace", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+
+		offset := 0
+		if rng := r.Header.Get("Range"); rng != "" {
+			offset, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		fmt.Fprint(w, trace[offset:chunks[polls]])
+		polls++
+	})
+
+	opt := &FollowTraceOptions{Interval: time.Millisecond, StripANSI: true, StripSections: true}
+	r := client.Jobs.FollowTrace(context.Background(), 1, 2, opt)
+	defer r.Close()
+
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Jobs.FollowTrace returned error: %v", err)
+	}
+
+	want := "Running\nbuilding\n\ndone\n"
+	if string(got) != want {
+		t.Errorf("Jobs.FollowTrace returned %q, want %q", got, want)
+	}
+}
+
+func TestParseTraceSections(t *testing.T) {
+	trace := strings.Join([]string{
+		"Preparing",
+		"section_start:100:prepare_script\r\x1b[0K\x1b[36;1mPreparing environment\x1b[0;m",
+		"Running on runner",
+		"section_end:103:prepare_script\r\x1b[0K",
+		"section_start:103:build_script[collapsed=true]\r\x1b[0KExecuting script",
+		"$ make",
+		"section_end:110:build_script\r\x1b[0K",
+		"Job succeeded",
+	}, "\n")
+
+	sections, err := ParseTraceSections(strings.NewReader(trace))
+	if err != nil {
+		t.Fatalf("ParseTraceSections returned error: %v", err)
+	}
+
+	if len(sections) != 4 {
+		t.Fatalf("ParseTraceSections returned %d sections, want 4", len(sections))
+	}
+
+	prepare := sections[1]
+	if prepare.Name != "prepare_script" || prepare.Header != "Preparing environment" || prepare.Duration != 3*time.Second {
+		t.Errorf("ParseTraceSections returned %+v", prepare)
+	}
+	if len(prepare.Lines) != 1 || prepare.Lines[0] != "Running on runner" {
+		t.Errorf("ParseTraceSections returned lines %q", prepare.Lines)
+	}
+
+	build := sections[2]
+	if build.Name != "build_script" || build.Duration != 7*time.Second || len(build.Lines) != 1 {
+		t.Errorf("ParseTraceSections returned %+v", build)
+	}
+
+	if sections[0].Name != "" || sections[3].Lines[0] != "Job succeeded" {
+		t.Errorf("ParseTraceSections returned %+v and %+v", sections[0], sections[3])
+	}
+}
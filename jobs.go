@@ -43,10 +43,14 @@ type Job struct {
 		Filename string `bson:"filename" json:"filename"`
 		Size     int    `bson:"size" json:"size"`
 	} `bson:"artifacts_file" json:"artifacts_file"`
-	FinishedAt *time.Time `bson:"finished_at" json:"finished_at"`
-	ID         int        `bson:"id" json:"id"`
-	Name       string     `bson:"name" json:"name"`
-	Pipeline   struct {
+	Artifacts         []JobArtifact `bson:"artifacts" json:"artifacts"`
+	ArtifactsExpireAt *time.Time    `bson:"artifacts_expire_at" json:"artifacts_expire_at"`
+	AllowFailure      bool          `bson:"allow_failure" json:"allow_failure"`
+	FailureReason     string        `bson:"failure_reason" json:"failure_reason"`
+	FinishedAt        *time.Time    `bson:"finished_at" json:"finished_at"`
+	ID                int           `bson:"id" json:"id"`
+	Name              string        `bson:"name" json:"name"`
+	Pipeline          struct {
 		ID     int    `bson:"id" json:"id"`
 		Ref    string `bson:"ref" json:"ref"`
 		Sha    string `bson:"sha" json:"sha"`
@@ -71,7 +75,8 @@ type Job struct {
 // ListJobsOptions are options for two list apis
 type ListJobsOptions struct {
 	ListOptions
-	Scope []BuildStateValue `url:"scope,omitempty" bson:"scope,omitempty" json:"scope,omitempty"`
+	Scope          []BuildStateValue `url:"scope,omitempty" bson:"scope,omitempty" json:"scope,omitempty"`
+	IncludeRetried *bool             `url:"include_retried,omitempty" bson:"include_retried,omitempty" json:"include_retried,omitempty"`
 }
 
 // ListProjectJobs gets a list of jobs in a project.
@@ -128,6 +133,64 @@ func (s *JobsService) ListPipelineJobs(pid interface{}, pipelineID int, opts *Li
 	return jobs, resp, err
 }
 
+// Bridge represents a bridge job, which triggers a downstream pipeline.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/jobs.html#list-pipeline-bridges
+type Bridge struct {
+	Commit             *Commit       `json:"commit"`
+	Coverage           float64       `json:"coverage"`
+	CreatedAt          *time.Time    `json:"created_at"`
+	StartedAt          *time.Time    `json:"started_at"`
+	FinishedAt         *time.Time    `json:"finished_at"`
+	Duration           float64       `json:"duration"`
+	ID                 int           `json:"id"`
+	Name               string        `json:"name"`
+	Pipeline           PipelineInfo  `json:"pipeline"`
+	Ref                string        `json:"ref"`
+	Stage              string        `json:"stage"`
+	Status             string        `json:"status"`
+	Tag                bool          `json:"tag"`
+	WebURL             string        `json:"web_url"`
+	User               *User         `json:"user"`
+	DownstreamPipeline *PipelineInfo `json:"downstream_pipeline"`
+}
+
+// ListPipelineBridgesOptions represents the available ListPipelineBridges()
+// options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/jobs.html#list-pipeline-bridges
+type ListPipelineBridgesOptions struct {
+	ListOptions
+	Scope []BuildStateValue `url:"scope,omitempty" json:"scope,omitempty"`
+}
+
+// ListPipelineBridges gets the bridge jobs of a single project pipeline.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/jobs.html#list-pipeline-bridges
+func (s *JobsService) ListPipelineBridges(pid interface{}, pipeline int, opt *ListPipelineBridgesOptions, options ...OptionFunc) ([]*Bridge, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/pipelines/%d/bridges", url.QueryEscape(project), pipeline)
+
+	req, err := s.client.NewRequest("GET", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var b []*Bridge
+	resp, err := s.client.Do(req, &b)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return b, resp, err
+}
+
 // GetJob gets a single job of a project.
 //
 // GitLab API docs:
@@ -26,3 +26,25 @@ func TestListPipelineJobs(t *testing.T) {
 		t.Errorf("Jobs.ListPipelineJobs returned %+v, want %+v", jobs, want)
 	}
 }
+
+func TestListPipelineBridges(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/1/bridges", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testURL(t, r, "/api/v4/projects/1/pipelines/1/bridges?scope=success")
+		fmt.Fprint(w, `[{"id":1,"downstream_pipeline":{"id":2,"project_id":3}}]`)
+	})
+
+	opt := &ListPipelineBridgesOptions{Scope: []BuildStateValue{Success}}
+	bridges, _, err := client.Jobs.ListPipelineBridges(1, 1, opt)
+	if err != nil {
+		t.Errorf("Jobs.ListPipelineBridges returned error: %v", err)
+	}
+
+	want := []*Bridge{{ID: 1, DownstreamPipeline: &PipelineInfo{ID: 2, ProjectID: 3}}}
+	if !reflect.DeepEqual(want, bridges) {
+		t.Errorf("Jobs.ListPipelineBridges returned %+v, want %+v", bridges, want)
+	}
+}
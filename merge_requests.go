@@ -93,19 +93,10 @@ type MergeRequest struct {
 	ForceRemoveSourceBranch  bool       `json:"force_remove_source_branch"`
 	WebURL                   string     `json:"web_url"`
 	DiscussionLocked         bool       `json:"discussion_locked"`
-	Changes                  []struct {
-		OldPath     string `json:"old_path"`
-		NewPath     string `json:"new_path"`
-		AMode       string `json:"a_mode"`
-		BMode       string `json:"b_mode"`
-		Diff        string `json:"diff"`
-		NewFile     bool   `json:"new_file"`
-		RenamedFile bool   `json:"renamed_file"`
-		DeletedFile bool   `json:"deleted_file"`
-	} `json:"changes"`
-	TimeStats *TimeStats `json:"time_stats"`
-	Squash    bool       `json:"squash"`
-	Pipeline  struct {
+	Changes                  []*Diff    `json:"changes"`
+	TimeStats                *TimeStats `json:"time_stats"`
+	Squash                   bool       `json:"squash"`
+	Pipeline                 struct {
 		ID     int    `json:"id"`
 		Ref    string `json:"ref"`
 		SHA    string `json:"sha"`
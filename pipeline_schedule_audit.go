@@ -0,0 +1,294 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PipelineScheduleIssueValue represents an issue found by a pipeline
+// schedule audit.
+type PipelineScheduleIssueValue string
+
+// List of available pipeline schedule issues.
+const (
+	PipelineScheduleInactive        PipelineScheduleIssueValue = "inactive"
+	PipelineScheduleOwnerBlocked    PipelineScheduleIssueValue = "owner_blocked"
+	PipelineScheduleOwnerMissing    PipelineScheduleIssueValue = "owner_missing"
+	PipelineScheduleInvalidCron     PipelineScheduleIssueValue = "invalid_cron"
+	PipelineScheduleNextRunMismatch PipelineScheduleIssueValue = "next_run_mismatch"
+	PipelineScheduleOverlap         PipelineScheduleIssueValue = "overlap"
+)
+
+// PipelineScheduleFinding represents an issue with a pipeline schedule.
+type PipelineScheduleFinding struct {
+	Project  interface{}
+	Schedule *PipelineSchedule
+	Issue    PipelineScheduleIssueValue
+	Detail   string
+
+	// Other and At are set for overlaps: Other is the schedule running on
+	// the same ref, and At the first time both run close to each other.
+	Other *PipelineSchedule
+	At    *time.Time
+}
+
+// AuditPipelineSchedulesOptions represents the available
+// AuditPipelineSchedules() options.
+type AuditPipelineSchedulesOptions struct {
+	// Projects are the IDs or paths of the projects to audit. If empty,
+	// all projects the user is a member of are audited.
+	Projects []interface{}
+
+	// Now is the time the audit starts from. Defaults to the current time.
+	Now time.Time
+
+	// Horizon is the period after Now that is checked for overlapping
+	// runs. Defaults to 7 days.
+	Horizon time.Duration
+
+	// OverlapWindow is the maximum time between two runs on the same ref
+	// to be reported as an overlap. Defaults to 15 minutes.
+	OverlapWindow time.Duration
+
+	// NextRunTolerance is passed to NextRunAtMatches.
+	NextRunTolerance time.Duration
+
+	// Concurrency limits the number of projects audited concurrently.
+	// Defaults to 1.
+	Concurrency int
+}
+
+// AuditPipelineSchedules checks the pipeline schedules of the given
+// projects. It reports inactive schedules, schedules owned by blocked or
+// deleted users, schedules with an invalid cron expression or a NextRunAt
+// that disagrees with it, and active schedules on the same ref that run
+// close to each other.
+func (s *PipelineSchedulesService) AuditPipelineSchedules(opt *AuditPipelineSchedulesOptions, options ...OptionFunc) ([]*PipelineScheduleFinding, error) {
+	if opt == nil {
+		opt = &AuditPipelineSchedulesOptions{}
+	}
+
+	projects := opt.Projects
+	if len(projects) == 0 {
+		var err error
+		if projects, err = listAllProjectIDs(s.client, options); err != nil {
+			return nil, err
+		}
+	}
+
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	findings := make([][]*PipelineScheduleFinding, len(projects))
+	errs := make([]error, len(projects))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, pid := range projects {
+		wg.Add(1)
+		go func(i int, pid interface{}) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			schedules, err := s.listAllPipelineSchedules(pid, options)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			findings[i] = auditPipelineSchedules(pid, schedules, opt)
+		}(i, pid)
+	}
+	wg.Wait()
+
+	var all []*PipelineScheduleFinding
+	for i := range projects {
+		if errs[i] != nil {
+			return all, errs[i]
+		}
+		all = append(all, findings[i]...)
+	}
+
+	return all, nil
+}
+
+func auditPipelineSchedules(pid interface{}, schedules []*PipelineSchedule, opt *AuditPipelineSchedulesOptions) []*PipelineScheduleFinding {
+	now := opt.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	horizon := opt.Horizon
+	if horizon <= 0 {
+		horizon = 7 * 24 * time.Hour
+	}
+	window := opt.OverlapWindow
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+
+	var findings []*PipelineScheduleFinding
+	add := func(s *PipelineSchedule, issue PipelineScheduleIssueValue, detail string) *PipelineScheduleFinding {
+		f := &PipelineScheduleFinding{Project: pid, Schedule: s, Issue: issue, Detail: detail}
+		findings = append(findings, f)
+		return f
+	}
+
+	runs := make(map[int][]time.Time)
+	var active []*PipelineSchedule
+
+	for _, s := range schedules {
+		switch {
+		case s.Owner == nil || s.Owner.Username == "ghost":
+			add(s, PipelineScheduleOwnerMissing, "the owner of the schedule was deleted")
+		case s.Owner.State != "" && s.Owner.State != "active":
+			add(s, PipelineScheduleOwnerBlocked, fmt.Sprintf("the owner %s is %s", s.Owner.Username, s.Owner.State))
+		}
+
+		c, err := s.CronSchedule()
+		if err != nil {
+			add(s, PipelineScheduleInvalidCron, err.Error())
+			continue
+		}
+
+		if !s.Active {
+			add(s, PipelineScheduleInactive, "the schedule is inactive")
+			continue
+		}
+
+		if ok, _ := s.NextRunAtMatches(opt.NextRunTolerance); !ok {
+			detail := "next_run_at is not set"
+			if s.NextRunAt != nil {
+				detail = fmt.Sprintf("next_run_at %s does not match cron %q in %s",
+					s.NextRunAt.Format(time.RFC3339), s.Cron, c.Location())
+			}
+			add(s, PipelineScheduleNextRunMismatch, detail)
+		}
+
+		for t := c.Next(now); !t.IsZero() && t.Before(now.Add(horizon)); t = c.Next(t) {
+			runs[s.ID] = append(runs[s.ID], t)
+		}
+		active = append(active, s)
+	}
+
+	for i, a := range active {
+		for _, b := range active[i+1:] {
+			if a.Ref != b.Ref {
+				continue
+			}
+			if at, ok := firstOverlap(runs[a.ID], runs[b.ID], window); ok {
+				f := add(a, PipelineScheduleOverlap, fmt.Sprintf("runs within %s of schedule %d on ref %s", window, b.ID, a.Ref))
+				f.Other = b
+				f.At = &at
+			}
+		}
+	}
+
+	return findings
+}
+
+// firstOverlap returns the first time of a that is within window of a time
+// of b. Both lists must be sorted.
+func firstOverlap(a, b []time.Time, window time.Duration) (time.Time, bool) {
+	j := 0
+	for _, t := range a {
+		for j < len(b) && b[j].Before(t.Add(-window)) {
+			j++
+		}
+		if j < len(b) && !b[j].After(t.Add(window)) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// TakeOwnershipOfPipelineSchedules takes ownership of all schedules with an
+// owner_blocked or owner_missing finding, so they are owned by the user of
+// the client. The updated schedules are returned.
+func (s *PipelineSchedulesService) TakeOwnershipOfPipelineSchedules(findings []*PipelineScheduleFinding, options ...OptionFunc) ([]*PipelineSchedule, error) {
+	var updated []*PipelineSchedule
+	done := make(map[string]bool)
+
+	for _, f := range findings {
+		if f.Issue != PipelineScheduleOwnerBlocked && f.Issue != PipelineScheduleOwnerMissing {
+			continue
+		}
+
+		key := fmt.Sprintf("%v/%d", f.Project, f.Schedule.ID)
+		if done[key] {
+			continue
+		}
+		done[key] = true
+
+		ps, _, err := s.TakeOwnershipOfPipelineSchedule(f.Project, f.Schedule.ID, options...)
+		if err != nil {
+			return updated, err
+		}
+		updated = append(updated, ps)
+	}
+
+	return updated, nil
+}
+
+func (s *PipelineSchedulesService) listAllPipelineSchedules(pid interface{}, options []OptionFunc) ([]*PipelineSchedule, error) {
+	var schedules []*PipelineSchedule
+
+	opt := &ListPipelineSchedulesOptions{Page: 1, PerPage: 100}
+	for {
+		ss, resp, err := s.ListPipelineSchedules(pid, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, ss...)
+
+		if resp.NextPage == 0 {
+			return schedules, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func listAllProjectIDs(client *Client, options []OptionFunc) ([]interface{}, error) {
+	var ids []interface{}
+
+	// Only projects the user is a member of are listed, as all visible
+	// projects include every public project of the instance.
+	opt := &ListProjectsOptions{
+		ListOptions: ListOptions{Page: 1, PerPage: 100},
+		Membership:  Bool(true),
+		Simple:      Bool(true),
+	}
+	for {
+		ps, resp, err := client.Projects.ListProjects(opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ps {
+			ids = append(ids, p.ID)
+		}
+
+		if resp.NextPage == 0 {
+			return ids, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,65 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAuditPipelineSchedules(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testURL(t, r, "/api/v4/projects?membership=true&page=1&per_page=100&simple=true")
+		fmt.Fprint(w, `[{"id":1}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipeline_schedules", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[
+			{"id":1,"ref":"master","cron":"0 1 * * *","cron_timezone":"UTC","next_run_at":"2019-01-02T01:00:00Z","active":true,"owner":{"username":"alice","state":"active"}},
+			{"id":2,"ref":"master","cron":"5 1 * * *","cron_timezone":"UTC","next_run_at":"2019-01-02T01:05:00Z","active":true,"owner":{"username":"bob","state":"blocked"}},
+			{"id":3,"ref":"master","cron":"0 12 * * *","cron_timezone":"UTC","next_run_at":"2019-01-02T09:00:00Z","active":true,"owner":{"username":"alice","state":"active"}},
+			{"id":4,"ref":"develop","cron":"0 1 * * *","cron_timezone":"UTC","active":false,"owner":null},
+			{"id":5,"ref":"develop","cron":"0 25 * * *","cron_timezone":"UTC","active":true,"owner":{"username":"alice","state":"active"}}
+		]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipeline_schedules/2/take_ownership", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"id":2,"owner":{"username":"admin","state":"active"}}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipeline_schedules/4/take_ownership", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"id":4,"owner":{"username":"admin","state":"active"}}`)
+	})
+
+	now, _ := time.Parse(time.RFC3339, "2019-01-01T12:00:00Z")
+	findings, err := client.PipelineSchedules.AuditPipelineSchedules(&AuditPipelineSchedulesOptions{Now: now, Concurrency: 2})
+	if err != nil {
+		t.Fatalf("PipelineSchedules.AuditPipelineSchedules returned error: %v", err)
+	}
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, fmt.Sprintf("%d:%s", f.Schedule.ID, f.Issue))
+	}
+	want := "[2:owner_blocked 3:next_run_mismatch 4:owner_missing 4:inactive 5:invalid_cron 1:overlap]"
+	if fmt.Sprint(got) != want {
+		t.Fatalf("PipelineSchedules.AuditPipelineSchedules returned %v, want %s", got, want)
+	}
+
+	overlap := findings[len(findings)-1]
+	if overlap.Other.ID != 2 || overlap.At.Format(time.RFC3339) != "2019-01-02T01:00:00Z" {
+		t.Errorf("PipelineSchedules.AuditPipelineSchedules returned overlap with %d at %v", overlap.Other.ID, overlap.At)
+	}
+
+	updated, err := client.PipelineSchedules.TakeOwnershipOfPipelineSchedules(findings)
+	if err != nil {
+		t.Fatalf("PipelineSchedules.TakeOwnershipOfPipelineSchedules returned error: %v", err)
+	}
+	if len(updated) != 2 || updated[0].Owner.Username != "admin" || updated[1].ID != 4 {
+		t.Errorf("PipelineSchedules.TakeOwnershipOfPipelineSchedules returned %+v", updated)
+	}
+}
@@ -0,0 +1,429 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros are the predefined schedules supported in cron expressions.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// railsTimeZones maps the time zone names used by GitLab, which are the
+// names known to Rails, to IANA time zone names.
+var railsTimeZones = map[string]string{
+	"International Date Line West": "Etc/GMT+12",
+	"Midway Island":                "Pacific/Midway",
+	"American Samoa":               "Pacific/Pago_Pago",
+	"Hawaii":                       "Pacific/Honolulu",
+	"Alaska":                       "America/Juneau",
+	"Pacific Time (US & Canada)":   "America/Los_Angeles",
+	"Tijuana":                      "America/Tijuana",
+	"Mountain Time (US & Canada)":  "America/Denver",
+	"Arizona":                      "America/Phoenix",
+	"Chihuahua":                    "America/Chihuahua",
+	"Mazatlan":                     "America/Mazatlan",
+	"Central Time (US & Canada)":   "America/Chicago",
+	"Saskatchewan":                 "America/Regina",
+	"Guadalajara":                  "America/Mexico_City",
+	"Mexico City":                  "America/Mexico_City",
+	"Monterrey":                    "America/Monterrey",
+	"Central America":              "America/Guatemala",
+	"Eastern Time (US & Canada)":   "America/New_York",
+	"Indiana (East)":               "America/Indiana/Indianapolis",
+	"Bogota":                       "America/Bogota",
+	"Lima":                         "America/Lima",
+	"Quito":                        "America/Lima",
+	"Atlantic Time (Canada)":       "America/Halifax",
+	"Caracas":                      "America/Caracas",
+	"La Paz":                       "America/La_Paz",
+	"Santiago":                     "America/Santiago",
+	"Newfoundland":                 "America/St_Johns",
+	"Brasilia":                     "America/Sao_Paulo",
+	"Buenos Aires":                 "America/Argentina/Buenos_Aires",
+	"Montevideo":                   "America/Montevideo",
+	"Georgetown":                   "America/Guyana",
+	"Puerto Rico":                  "America/Puerto_Rico",
+	"Greenland":                    "America/Godthab",
+	"Mid-Atlantic":                 "Atlantic/South_Georgia",
+	"Azores":                       "Atlantic/Azores",
+	"Cape Verde Is.":               "Atlantic/Cape_Verde",
+	"Dublin":                       "Europe/Dublin",
+	"Edinburgh":                    "Europe/London",
+	"Lisbon":                       "Europe/Lisbon",
+	"London":                       "Europe/London",
+	"Casablanca":                   "Africa/Casablanca",
+	"Monrovia":                     "Africa/Monrovia",
+	"UTC":                          "Etc/UTC",
+	"Belgrade":                     "Europe/Belgrade",
+	"Bratislava":                   "Europe/Bratislava",
+	"Budapest":                     "Europe/Budapest",
+	"Ljubljana":                    "Europe/Ljubljana",
+	"Prague":                       "Europe/Prague",
+	"Sarajevo":                     "Europe/Sarajevo",
+	"Skopje":                       "Europe/Skopje",
+	"Warsaw":                       "Europe/Warsaw",
+	"Zagreb":                       "Europe/Zagreb",
+	"Brussels":                     "Europe/Brussels",
+	"Copenhagen":                   "Europe/Copenhagen",
+	"Madrid":                       "Europe/Madrid",
+	"Paris":                        "Europe/Paris",
+	"Amsterdam":                    "Europe/Amsterdam",
+	"Berlin":                       "Europe/Berlin",
+	"Bern":                         "Europe/Zurich",
+	"Zurich":                       "Europe/Zurich",
+	"Rome":                         "Europe/Rome",
+	"Stockholm":                    "Europe/Stockholm",
+	"Vienna":                       "Europe/Vienna",
+	"West Central Africa":          "Africa/Algiers",
+	"Bucharest":                    "Europe/Bucharest",
+	"Cairo":                        "Africa/Cairo",
+	"Helsinki":                     "Europe/Helsinki",
+	"Kyiv":                         "Europe/Kiev",
+	"Riga":                         "Europe/Riga",
+	"Sofia":                        "Europe/Sofia",
+	"Tallinn":                      "Europe/Tallinn",
+	"Vilnius":                      "Europe/Vilnius",
+	"Athens":                       "Europe/Athens",
+	"Istanbul":                     "Europe/Istanbul",
+	"Minsk":                        "Europe/Minsk",
+	"Jerusalem":                    "Asia/Jerusalem",
+	"Harare":                       "Africa/Harare",
+	"Pretoria":                     "Africa/Johannesburg",
+	"Kaliningrad":                  "Europe/Kaliningrad",
+	"Moscow":                       "Europe/Moscow",
+	"St. Petersburg":               "Europe/Moscow",
+	"Volgograd":                    "Europe/Volgograd",
+	"Samara":                       "Europe/Samara",
+	"Kuwait":                       "Asia/Kuwait",
+	"Riyadh":                       "Asia/Riyadh",
+	"Nairobi":                      "Africa/Nairobi",
+	"Baghdad":                      "Asia/Baghdad",
+	"Tehran":                       "Asia/Tehran",
+	"Abu Dhabi":                    "Asia/Muscat",
+	"Muscat":                       "Asia/Muscat",
+	"Baku":                         "Asia/Baku",
+	"Tbilisi":                      "Asia/Tbilisi",
+	"Yerevan":                      "Asia/Yerevan",
+	"Kabul":                        "Asia/Kabul",
+	"Ekaterinburg":                 "Asia/Yekaterinburg",
+	"Islamabad":                    "Asia/Karachi",
+	"Karachi":                      "Asia/Karachi",
+	"Tashkent":                     "Asia/Tashkent",
+	"Chennai":                      "Asia/Kolkata",
+	"Kolkata":                      "Asia/Kolkata",
+	"Mumbai":                       "Asia/Kolkata",
+	"New Delhi":                    "Asia/Kolkata",
+	"Kathmandu":                    "Asia/Kathmandu",
+	"Astana":                       "Asia/Dhaka",
+	"Dhaka":                        "Asia/Dhaka",
+	"Sri Jayawardenepura":          "Asia/Colombo",
+	"Almaty":                       "Asia/Almaty",
+	"Novosibirsk":                  "Asia/Novosibirsk",
+	"Rangoon":                      "Asia/Rangoon",
+	"Bangkok":                      "Asia/Bangkok",
+	"Hanoi":                        "Asia/Bangkok",
+	"Jakarta":                      "Asia/Jakarta",
+	"Krasnoyarsk":                  "Asia/Krasnoyarsk",
+	"Beijing":                      "Asia/Shanghai",
+	"Chongqing":                    "Asia/Chongqing",
+	"Hong Kong":                    "Asia/Hong_Kong",
+	"Urumqi":                       "Asia/Urumqi",
+	"Kuala Lumpur":                 "Asia/Kuala_Lumpur",
+	"Singapore":                    "Asia/Singapore",
+	"Taipei":                       "Asia/Taipei",
+	"Perth":                        "Australia/Perth",
+	"Irkutsk":                      "Asia/Irkutsk",
+	"Ulaanbaatar":                  "Asia/Ulaanbaatar",
+	"Seoul":                        "Asia/Seoul",
+	"Osaka":                        "Asia/Tokyo",
+	"Sapporo":                      "Asia/Tokyo",
+	"Tokyo":                        "Asia/Tokyo",
+	"Yakutsk":                      "Asia/Yakutsk",
+	"Darwin":                       "Australia/Darwin",
+	"Adelaide":                     "Australia/Adelaide",
+	"Canberra":                     "Australia/Melbourne",
+	"Melbourne":                    "Australia/Melbourne",
+	"Sydney":                       "Australia/Sydney",
+	"Brisbane":                     "Australia/Brisbane",
+	"Hobart":                       "Australia/Hobart",
+	"Vladivostok":                  "Asia/Vladivostok",
+	"Guam":                         "Pacific/Guam",
+	"Port Moresby":                 "Pacific/Port_Moresby",
+	"Magadan":                      "Asia/Magadan",
+	"Srednekolymsk":                "Asia/Srednekolymsk",
+	"Solomon Is.":                  "Pacific/Guadalcanal",
+	"New Caledonia":                "Pacific/Noumea",
+	"Fiji":                         "Pacific/Fiji",
+	"Kamchatka":                    "Asia/Kamchatka",
+	"Marshall Is.":                 "Pacific/Majuro",
+	"Auckland":                     "Pacific/Auckland",
+	"Wellington":                   "Pacific/Auckland",
+	"Nuku'alofa":                   "Pacific/Tongatapu",
+	"Tokelau Is.":                  "Pacific/Fakaofo",
+	"Chatham Is.":                  "Pacific/Chatham",
+	"Samoa":                        "Pacific/Apia",
+}
+
+// CronSchedule represents a parsed cron expression in a time zone.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar report whether the day of month and day of week
+	// fields are unrestricted. If both are restricted, a day matches if
+	// either of them matches.
+	domStar, dowStar bool
+
+	loc *time.Location
+}
+
+// ParseCron parses a standard five field cron expression, or one of the
+// macros like @daily, in the given time zone. The time zone is either an
+// IANA name or one of the names used by GitLab, like "Amsterdam". An empty
+// time zone means UTC.
+func ParseCron(spec, timezone string) (*CronSchedule, error) {
+	loc, err := loadCronLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	expr := strings.TrimSpace(spec)
+	if m, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = m
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	c := &CronSchedule{loc: loc}
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v", spec, err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v", spec, err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v", spec, err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v", spec, err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v", spec, err)
+	}
+
+	// Both 0 and 7 mean Sunday.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	c.domStar = strings.HasPrefix(fields[2], "*") || fields[2] == "?"
+	c.dowStar = strings.HasPrefix(fields[4], "*") || fields[4] == "?"
+
+	return c, nil
+}
+
+func loadCronLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	if name, ok := railsTimeZones[timezone]; ok {
+		timezone = name
+	}
+	return time.LoadLocation(timezone)
+}
+
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			i := strings.Index(rng, "-")
+			var err error
+			if lo, err = parseCronValue(rng[:i], names); err != nil {
+				return 0, err
+			}
+			if hi, err = parseCronValue(rng[i+1:], names); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if lo, err = parseCronValue(rng, names); err != nil {
+				return 0, err
+			}
+			// A single value with a step, like 5/15, runs until the max.
+			hi = lo
+			if strings.Contains(part, "/") {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseCronValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// Location returns the time zone of the schedule.
+func (c *CronSchedule) Location() *time.Location {
+	return c.loc
+}
+
+// Next returns the first time after t at which the schedule runs, in the
+// time zone of the schedule. Times skipped by a daylight saving time change
+// don't run. The zero time is returned if the schedule never runs, like on
+// February 30th.
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	t = t.In(c.loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, c.loc).Add(time.Minute)
+
+	// Every valid schedule runs at least once in 8 years, as February
+	// 29th may fall on any day of the week.
+	limit := t.Year() + 8
+
+	for t.Year() <= limit {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// NextN returns the next n times after t at which the schedule runs.
+func (c *CronSchedule) NextN(t time.Time, n int) []time.Time {
+	var times []time.Time
+	for len(times) < n {
+		t = c.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// CronSchedule parses the cron expression of the pipeline schedule in its
+// time zone.
+func (s *PipelineSchedule) CronSchedule() (*CronSchedule, error) {
+	return ParseCron(s.Cron, s.CronTimezone)
+}
+
+// NextRuns returns the next n times after t at which the pipeline schedule
+// is due according to its cron expression.
+func (s *PipelineSchedule) NextRuns(t time.Time, n int) ([]time.Time, error) {
+	c, err := s.CronSchedule()
+	if err != nil {
+		return nil, err
+	}
+	return c.NextN(t, n), nil
+}
+
+// NextRunAtMatches reports whether NextRunAt of the pipeline schedule agrees
+// with its cron expression. GitLab starts scheduled pipelines from a
+// periodic worker, so NextRunAt may be up to tolerance later than the time
+// the cron expression is due. A tolerance of zero defaults to one hour, the
+// default interval of the worker.
+func (s *PipelineSchedule) NextRunAtMatches(tolerance time.Duration) (bool, error) {
+	c, err := s.CronSchedule()
+	if err != nil {
+		return false, err
+	}
+	if s.NextRunAt == nil {
+		return !s.Active, nil
+	}
+	if tolerance <= 0 {
+		tolerance = time.Hour
+	}
+
+	due := c.Next(s.NextRunAt.Add(-tolerance - time.Minute))
+	return !due.IsZero() && !due.After(*s.NextRunAt), nil
+}
@@ -0,0 +1,83 @@
+package gitlab
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		spec string
+		tz   string
+		from string
+		want []string
+	}{
+		{"*/15 * * * *", "", "2019-01-01T10:07:00Z", []string{"2019-01-01T10:15:00Z", "2019-01-01T10:30:00Z", "2019-01-01T10:45:00Z"}},
+		{"@daily", "UTC", "2019-01-01T10:07:00Z", []string{"2019-01-02T00:00:00Z", "2019-01-03T00:00:00Z", "2019-01-04T00:00:00Z"}},
+		{"0 9 * * mon-fri", "Amsterdam", "2019-01-04T12:00:00Z", []string{"2019-01-07T08:00:00Z", "2019-01-08T08:00:00Z", "2019-01-09T08:00:00Z"}},
+		{"30 2 * * *", "Europe/Amsterdam", "2019-03-30T12:00:00Z", []string{"2019-04-01T00:30:00Z", "2019-04-02T00:30:00Z", "2019-04-03T00:30:00Z"}},
+		{"0 0 13 * 5", "", "2019-01-01T00:00:00Z", []string{"2019-01-04T00:00:00Z", "2019-01-11T00:00:00Z", "2019-01-13T00:00:00Z"}},
+		{"0 0 29 feb *", "", "2019-01-01T00:00:00Z", []string{"2020-02-29T00:00:00Z", "2024-02-29T00:00:00Z", "2028-02-29T00:00:00Z"}},
+		{"0 0 * * 7", "", "2019-01-01T00:00:00Z", []string{"2019-01-06T00:00:00Z", "2019-01-13T00:00:00Z", "2019-01-20T00:00:00Z"}},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec, tt.tz)
+		if err != nil {
+			t.Fatalf("ParseCron(%q, %q) returned error: %v", tt.spec, tt.tz, err)
+		}
+		from, _ := time.Parse(time.RFC3339, tt.from)
+
+		var got []string
+		for _, n := range c.NextN(from, 3) {
+			got = append(got, n.UTC().Format(time.RFC3339))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCron(%q, %q).NextN returned %v, want %v", tt.spec, tt.tz, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := ParseCron(spec, ""); err == nil {
+			t.Errorf("ParseCron(%q) returned no error", spec)
+		}
+	}
+	if _, err := ParseCron("* * * * *", "Middle of Nowhere"); err == nil {
+		t.Errorf("ParseCron with an unknown time zone returned no error")
+	}
+
+	c, _ := ParseCron("0 0 30 2 *", "")
+	if n := c.Next(time.Now()); !n.IsZero() {
+		t.Errorf("Next returned %v, want the zero time", n)
+	}
+}
+
+func TestPipelineScheduleNextRunAtMatches(t *testing.T) {
+	at := func(s string) *time.Time {
+		v, _ := time.Parse(time.RFC3339, s)
+		return &v
+	}
+
+	tests := []struct {
+		schedule *PipelineSchedule
+		want     bool
+	}{
+		{&PipelineSchedule{Cron: "0 1 * * *", CronTimezone: "UTC", NextRunAt: at("2019-01-02T01:00:00Z"), Active: true}, true},
+		{&PipelineSchedule{Cron: "0 1 * * *", CronTimezone: "UTC", NextRunAt: at("2019-01-02T01:19:00Z"), Active: true}, true},
+		{&PipelineSchedule{Cron: "0 1 * * *", CronTimezone: "UTC", NextRunAt: at("2019-01-02T03:00:00Z"), Active: true}, false},
+		{&PipelineSchedule{Cron: "0 1 * * *", CronTimezone: "Tokyo", NextRunAt: at("2019-01-01T16:00:00Z"), Active: true}, true},
+		{&PipelineSchedule{Cron: "0 1 * * *", CronTimezone: "UTC", Active: true}, false},
+		{&PipelineSchedule{Cron: "0 1 * * *", CronTimezone: "UTC"}, true},
+	}
+
+	for i, tt := range tests {
+		got, err := tt.schedule.NextRunAtMatches(0)
+		if err != nil {
+			t.Fatalf("%d: NextRunAtMatches returned error: %v", i, err)
+		}
+		if got != tt.want {
+			t.Errorf("%d: NextRunAtMatches returned %v, want %v", i, got, tt.want)
+		}
+	}
+}
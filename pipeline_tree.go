@@ -0,0 +1,254 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// parallelJobSuffixRegexp matches the suffix GitLab adds to the names of
+// parallel jobs, e.g. "test 1/3".
+var parallelJobSuffixRegexp = regexp.MustCompile(` \d+/\d+$`)
+
+// defaultCIStages are the stages of a CI configuration without a stages
+// keyword.
+var defaultCIStages = []string{"build", "test", "deploy"}
+
+// PipelineTree represents a pipeline together with its jobs and all
+// downstream pipelines triggered by its bridge jobs.
+type PipelineTree struct {
+	Pipeline *Pipeline
+
+	// Trigger is the bridge job of the upstream pipeline that triggered
+	// this pipeline. It is nil for the root of the tree.
+	Trigger *Bridge
+
+	// MultiProject reports whether the pipeline runs in another project
+	// than its upstream pipeline. Otherwise it is a child pipeline.
+	MultiProject bool
+
+	// Stages contains the names of the stages in the order they run.
+	Stages []string
+
+	Jobs       []*PipelineTreeJob
+	Bridges    []*Bridge
+	Downstream []*PipelineTree
+}
+
+// PipelineTreeJob represents a job in a pipeline tree, together with the
+// names of the jobs it depends on.
+type PipelineTreeJob struct {
+	Job   *Job
+	Needs []string
+}
+
+// PipelineTreeOptions represents the available GetPipelineTree() options.
+type PipelineTreeOptions struct {
+	// MaxDepth limits the number of downstream levels that are followed.
+	// Zero means no limit.
+	MaxDepth int
+
+	// ResolveNeeds reads the stages and the needs of the jobs from the CI
+	// configuration of the project at the SHA of the pipeline. An error is
+	// returned if the configuration is invalid. Without it, and for child
+	// pipelines whose configuration is not known, stages are ordered as they
+	// first appear in the job list and every job depends on all jobs and
+	// bridges of the previous stage.
+	ResolveNeeds bool
+}
+
+// GetPipelineTree gets a pipeline with its jobs and follows its bridge jobs
+// recursively to build the complete tree of child and multi-project
+// pipelines.
+func (s *PipelinesService) GetPipelineTree(pid interface{}, pipeline int, opt *PipelineTreeOptions, options ...OptionFunc) (*PipelineTree, error) {
+	if opt == nil {
+		opt = &PipelineTreeOptions{}
+	}
+	return s.pipelineTree(pid, pipeline, nil, 0, opt, make(map[int]bool), options)
+}
+
+func (s *PipelinesService) pipelineTree(pid interface{}, pipeline int, trigger *Bridge, depth int, opt *PipelineTreeOptions, visited map[int]bool, options []OptionFunc) (*PipelineTree, error) {
+	visited[pipeline] = true
+
+	p, _, err := s.GetPipeline(pid, pipeline, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := s.listAllPipelineJobs(pid, pipeline, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	bridges, err := s.listAllPipelineBridges(pid, pipeline, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &PipelineTree{Pipeline: p, Trigger: trigger, Bridges: bridges}
+	if trigger != nil && trigger.DownstreamPipeline != nil {
+		t.MultiProject = trigger.Pipeline.ProjectID != trigger.DownstreamPipeline.ProjectID
+	}
+
+	var config *CIConfig
+	if opt.ResolveNeeds && (trigger == nil || t.MultiProject) {
+		config, err = s.pipelineConfig(pid, p.SHA, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.setJobs(jobs, config)
+
+	for _, b := range bridges {
+		d := b.DownstreamPipeline
+		if d == nil || visited[d.ID] || (opt.MaxDepth > 0 && depth >= opt.MaxDepth) {
+			continue
+		}
+
+		child, err := s.pipelineTree(d.ProjectID, d.ID, b, depth+1, opt, visited, options)
+		if err != nil {
+			return nil, err
+		}
+		t.Downstream = append(t.Downstream, child)
+	}
+
+	return t, nil
+}
+
+// pipelineConfig returns the merged CI configuration of a project at the
+// given SHA.
+func (s *PipelinesService) pipelineConfig(pid interface{}, sha string, options []OptionFunc) (*CIConfig, error) {
+	l, _, err := s.client.Validate.ProjectLint(pid, &ProjectLintOptions{SHA: String(sha)}, options...)
+	if err != nil {
+		return nil, err
+	}
+	if !l.Valid {
+		return nil, fmt.Errorf("invalid CI configuration at %s: %s", sha, strings.Join(l.Errors, "; "))
+	}
+
+	c, err := l.Config()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ResolveExtends(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (t *PipelineTree) setJobs(jobs []*Job, config *CIConfig) {
+	type stagedJob struct {
+		name  string
+		stage string
+	}
+	var all []stagedJob
+	for _, j := range jobs {
+		all = append(all, stagedJob{j.Name, j.Stage})
+	}
+	for _, b := range t.Bridges {
+		all = append(all, stagedJob{b.Name, b.Stage})
+	}
+
+	stageJobs := make(map[string][]string)
+	var appearance []string
+	for _, j := range all {
+		if _, ok := stageJobs[j.stage]; !ok {
+			appearance = append(appearance, j.stage)
+		}
+		stageJobs[j.stage] = append(stageJobs[j.stage], j.name)
+	}
+
+	// Stages run in the order of the CI configuration. Job IDs don't tell
+	// the order, as retried jobs get new IDs. Stages that aren't known
+	// from the configuration keep the order of the job list.
+	var order []string
+	if config != nil {
+		order = config.Stages
+		if len(order) == 0 {
+			order = defaultCIStages
+		}
+		order = append(append([]string{".pre"}, order...), ".post")
+	}
+	stageIndex := make(map[string]int)
+	for _, stage := range append(order, appearance...) {
+		if _, ok := stageJobs[stage]; !ok {
+			continue
+		}
+		if _, ok := stageIndex[stage]; !ok {
+			stageIndex[stage] = len(t.Stages)
+			t.Stages = append(t.Stages, stage)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+
+	// Parallel jobs share the name of their configuration.
+	instances := make(map[string][]string)
+	for _, j := range jobs {
+		base := parallelJobSuffixRegexp.ReplaceAllString(j.Name, "")
+		instances[base] = append(instances[base], j.Name)
+	}
+	for _, b := range t.Bridges {
+		base := parallelJobSuffixRegexp.ReplaceAllString(b.Name, "")
+		instances[base] = append(instances[base], b.Name)
+	}
+
+	for _, j := range jobs {
+		tj := &PipelineTreeJob{Job: j}
+
+		var cj *CIJob
+		if config != nil {
+			cj = config.Job(parallelJobSuffixRegexp.ReplaceAllString(j.Name, ""))
+		}
+
+		if cj != nil && cj.Needs != nil {
+			tj.Needs = []string{}
+			for _, n := range cj.Needs {
+				if n.Project != "" || n.Pipeline != "" {
+					continue
+				}
+				tj.Needs = append(tj.Needs, instances[n.Job]...)
+			}
+		} else if i := stageIndex[j.Stage]; i > 0 {
+			tj.Needs = stageJobs[t.Stages[i-1]]
+		}
+
+		t.Jobs = append(t.Jobs, tj)
+	}
+}
+
+func (s *PipelinesService) listAllPipelineBridges(pid interface{}, pipeline int, options ...OptionFunc) ([]*Bridge, error) {
+	var bridges []*Bridge
+
+	opt := &ListPipelineBridgesOptions{ListOptions: ListOptions{Page: 1, PerPage: 100}}
+	for {
+		bs, resp, err := s.client.Jobs.ListPipelineBridges(pid, pipeline, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		bridges = append(bridges, bs...)
+
+		if resp.NextPage == 0 {
+			return bridges, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,118 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetPipelineTree(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/10", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":10,"project_id":1,"sha":"abc","status":"running"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/10/jobs", func(w http.ResponseWriter, r *http.Request) {
+		// The build job was retried, so it has the highest ID.
+		fmt.Fprint(w, `[
+			{"id":4,"name":"deploy","stage":"deploy"},
+			{"id":3,"name":"test 2/2","stage":"test"},
+			{"id":2,"name":"test 1/2","stage":"test"},
+			{"id":7,"name":"build","stage":"build"}
+		]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/10/bridges", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id":5,"name":"child","stage":"deploy","pipeline":{"id":10,"project_id":1},"downstream_pipeline":{"id":11,"project_id":1}},
+			{"id":6,"name":"downstream","stage":"deploy","pipeline":{"id":10,"project_id":1},"downstream_pipeline":{"id":20,"project_id":2}}
+		]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/ci/lint", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sha") != "abc" {
+			t.Errorf("Request query is %v", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `{"valid":true,"merged_yaml":"build:\n  script: make\ntest:\n  parallel: 2\n  script: make test\ndeploy:\n  needs: [test]\n  script: make deploy\n"}`)
+	})
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/11", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":11,"project_id":1,"status":"success"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/2/pipelines/20", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":20,"project_id":2,"status":"success"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/11/jobs", func(w http.ResponseWriter, r *http.Request) {
+		// Without a configuration, stages keep the order of the job list.
+		fmt.Fprint(w, `[
+			{"id":13,"name":"lint","stage":"check"},
+			{"id":12,"name":"unit","stage":"test"}
+		]`)
+	})
+	for _, path := range []string{"/api/v4/projects/1/pipelines/11/bridges", "/api/v4/projects/2/pipelines/20/"} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[]`)
+		})
+	}
+	mux.HandleFunc("/api/v4/projects/2/ci/lint", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"valid":true,"merged_yaml":""}`)
+	})
+
+	tree, err := client.Pipelines.GetPipelineTree(1, 10, &PipelineTreeOptions{ResolveNeeds: true})
+	if err != nil {
+		t.Fatalf("Pipelines.GetPipelineTree returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual([]string{"build", "test", "deploy"}, tree.Stages) {
+		t.Errorf("Pipelines.GetPipelineTree returned stages %v", tree.Stages)
+	}
+
+	needs := make(map[string][]string)
+	for _, j := range tree.Jobs {
+		needs[j.Job.Name] = j.Needs
+	}
+	want := map[string][]string{
+		"build":    nil,
+		"test 1/2": {"build"},
+		"test 2/2": {"build"},
+		"deploy":   {"test 1/2", "test 2/2"},
+	}
+	if !reflect.DeepEqual(want, needs) {
+		t.Errorf("Pipelines.GetPipelineTree returned needs %v, want %v", needs, want)
+	}
+
+	if len(tree.Downstream) != 2 {
+		t.Fatalf("Pipelines.GetPipelineTree returned %d downstream pipelines, want 2", len(tree.Downstream))
+	}
+	child, multi := tree.Downstream[0], tree.Downstream[1]
+	if child.Pipeline.ID != 11 || child.MultiProject || child.Trigger.Name != "child" {
+		t.Errorf("Pipelines.GetPipelineTree returned child %+v", child)
+	}
+	if !reflect.DeepEqual([]string{"check", "test"}, child.Stages) {
+		t.Errorf("Pipelines.GetPipelineTree returned child stages %v", child.Stages)
+	}
+	if multi.Pipeline.ID != 20 || !multi.MultiProject {
+		t.Errorf("Pipelines.GetPipelineTree returned downstream %+v", multi)
+	}
+}
+
+func TestGetPipelineTreeInvalidConfig(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/10", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":10,"project_id":1,"sha":"abc","status":"running"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/10/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/ci/lint", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"valid":false,"errors":["jobs config should contain at least one visible job"]}`)
+	})
+
+	_, err := client.Pipelines.GetPipelineTree(1, 10, &PipelineTreeOptions{ResolveNeeds: true})
+	if err == nil || !strings.Contains(err.Error(), "at least one visible job") {
+		t.Errorf("Pipelines.GetPipelineTree returned %v, want the lint error", err)
+	}
+}
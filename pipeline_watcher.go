@@ -0,0 +1,190 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"context"
+	"time"
+)
+
+// IsTerminalPipelineStatus reports whether a pipeline or job with the given
+// status will not change its status anymore without user interaction. A
+// manual status is considered terminal, as it waits for a user to start a
+// manual job.
+func IsTerminalPipelineStatus(status string) bool {
+	switch status {
+	case "success", "failed", "canceled", "skipped", "manual":
+		return true
+	}
+	return false
+}
+
+// PipelineStatusEvent represents a status change of a pipeline or of one of
+// its jobs. Job is nil for status changes of the pipeline itself.
+type PipelineStatusEvent struct {
+	Pipeline       *Pipeline
+	Job            *Job
+	PreviousStatus string
+	Status         string
+}
+
+// WatchPipelineOptions represents the available WatchPipeline() options.
+type WatchPipelineOptions struct {
+	// MinInterval and MaxInterval bound the time between two polls. The
+	// interval starts at MinInterval, grows while nothing changes and is
+	// reset after every change. They default to 2 and 30 seconds.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+
+	// Timeout limits the total time spent waiting. Zero means no timeout.
+	Timeout time.Duration
+
+	// WatchJobs enables status change events for the jobs of the pipeline.
+	WatchJobs bool
+
+	// CancelOnAbort cancels the pipeline when the timeout expires or the
+	// context is canceled before the pipeline finished.
+	CancelOnAbort bool
+
+	// OnStatusChange is called for every status change, including the
+	// initial status of the pipeline and its jobs.
+	OnStatusChange func(*PipelineStatusEvent)
+}
+
+// WatchPipeline polls a pipeline until it reaches a terminal status and
+// returns the final pipeline. When the timeout expires or the context is
+// canceled first, the last known pipeline is returned together with the
+// context error.
+func (s *PipelinesService) WatchPipeline(ctx context.Context, pid interface{}, pipeline int, opt *WatchPipelineOptions, options ...OptionFunc) (*Pipeline, error) {
+	if opt == nil {
+		opt = &WatchPipelineOptions{}
+	}
+
+	minInterval := opt.MinInterval
+	if minInterval <= 0 {
+		minInterval = 2 * time.Second
+	}
+	maxInterval := opt.MaxInterval
+	if maxInterval < minInterval {
+		maxInterval = 30 * time.Second
+		if maxInterval < minInterval {
+			maxInterval = minInterval
+		}
+	}
+
+	if opt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opt.Timeout)
+		defer cancel()
+	}
+
+	notify := func(e *PipelineStatusEvent) {
+		if opt.OnStatusChange != nil {
+			opt.OnStatusChange(e)
+		}
+	}
+
+	abort := func(p *Pipeline) (*Pipeline, error) {
+		if opt.CancelOnAbort {
+			if c, _, err := s.CancelPipelineBuild(pid, pipeline, options...); err == nil {
+				p = c
+			}
+		}
+		return p, ctx.Err()
+	}
+
+	var last *Pipeline
+	jobStatus := make(map[int]string)
+	interval := minInterval
+
+	for {
+		reqOptions := append(options[:len(options):len(options)], WithContext(ctx))
+
+		p, _, err := s.GetPipeline(pid, pipeline, reqOptions...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return abort(last)
+			}
+			return last, err
+		}
+
+		changed := false
+		if last == nil || last.Status != p.Status {
+			e := &PipelineStatusEvent{Pipeline: p, Status: p.Status}
+			if last != nil {
+				e.PreviousStatus = last.Status
+			}
+			notify(e)
+			changed = true
+		}
+		last = p
+
+		if opt.WatchJobs {
+			jobs, err := s.listAllPipelineJobs(pid, pipeline, reqOptions...)
+			if err != nil {
+				if ctx.Err() != nil {
+					return abort(last)
+				}
+				return last, err
+			}
+
+			for _, j := range jobs {
+				if prev, ok := jobStatus[j.ID]; !ok || prev != j.Status {
+					notify(&PipelineStatusEvent{Pipeline: p, Job: j, PreviousStatus: prev, Status: j.Status})
+					jobStatus[j.ID] = j.Status
+					changed = true
+				}
+			}
+		}
+
+		if IsTerminalPipelineStatus(p.Status) {
+			return p, nil
+		}
+
+		if changed {
+			interval = minInterval
+		} else if interval = interval * 3 / 2; interval > maxInterval {
+			interval = maxInterval
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return abort(last)
+		}
+	}
+}
+
+func (s *PipelinesService) listAllPipelineJobs(pid interface{}, pipeline int, options ...OptionFunc) ([]*Job, error) {
+	var jobs []*Job
+
+	opt := &ListJobsOptions{ListOptions: ListOptions{Page: 1, PerPage: 100}}
+	for {
+		js, resp, err := s.client.Jobs.ListPipelineJobs(pid, pipeline, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, js...)
+
+		if resp.NextPage == 0 {
+			return jobs, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
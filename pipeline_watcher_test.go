@@ -0,0 +1,90 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchPipeline(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	statuses := []string{"pending", "running", "running", "success"}
+	polls := 0
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprintf(w, `{"id":2,"status":%q}`, statuses[polls])
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/2/jobs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprintf(w, `[{"id":3,"status":%q}]`, statuses[polls])
+		polls++
+	})
+
+	var events []string
+	opt := &WatchPipelineOptions{
+		MinInterval: time.Millisecond,
+		MaxInterval: time.Millisecond,
+		WatchJobs:   true,
+		OnStatusChange: func(e *PipelineStatusEvent) {
+			kind := "pipeline"
+			if e.Job != nil {
+				kind = "job"
+			}
+			events = append(events, fmt.Sprintf("%s:%s->%s", kind, e.PreviousStatus, e.Status))
+		},
+	}
+
+	p, err := client.Pipelines.WatchPipeline(context.Background(), 1, 2, opt)
+	if err != nil {
+		t.Fatalf("Pipelines.WatchPipeline returned error: %v", err)
+	}
+	if p.Status != "success" {
+		t.Errorf("Pipelines.WatchPipeline returned status %s, want success", p.Status)
+	}
+
+	want := []string{
+		"pipeline:->pending",
+		"job:->pending",
+		"pipeline:pending->running",
+		"job:pending->running",
+		"pipeline:running->success",
+		"job:running->success",
+	}
+	if !reflect.DeepEqual(want, events) {
+		t.Errorf("Pipelines.WatchPipeline emitted %v, want %v", events, want)
+	}
+}
+
+func TestWatchPipelineCancelOnAbort(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":2,"status":"running"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipelines/2/cancel", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"id":2,"status":"canceled"}`)
+	})
+
+	opt := &WatchPipelineOptions{
+		MinInterval:   time.Millisecond,
+		Timeout:       20 * time.Millisecond,
+		CancelOnAbort: true,
+	}
+
+	p, err := client.Pipelines.WatchPipeline(context.Background(), 1, 2, opt)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Pipelines.WatchPipeline returned error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if p == nil || p.Status != "canceled" {
+		t.Errorf("Pipelines.WatchPipeline returned %+v, want canceled pipeline", p)
+	}
+}
@@ -43,6 +43,7 @@ type PipelineVariable struct {
 // GitLab API docs: https://docs.gitlab.com/ce/api/pipelines.html
 type Pipeline struct {
 	ID         int    `json:"id"`
+	ProjectID  int    `json:"project_id"`
 	Status     string `json:"status"`
 	Ref        string `json:"ref"`
 	SHA        string `json:"sha"`
@@ -229,3 +230,180 @@ func (s *PipelinesService) CancelPipelineBuild(pid interface{}, pipelineID int,
 
 	return p, resp, err
 }
+
+// PipelineInfo represents the basic information of a pipeline, as embedded
+// in other resources.
+type PipelineInfo struct {
+	ID        int        `json:"id"`
+	ProjectID int        `json:"project_id"`
+	Status    string     `json:"status"`
+	Ref       string     `json:"ref"`
+	SHA       string     `json:"sha"`
+	WebURL    string     `json:"web_url"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// GetPipelineVariables gets the variables of a single project pipeline.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/pipelines.html#get-variables-of-a-pipeline
+func (s *PipelinesService) GetPipelineVariables(pid interface{}, pipeline int, options ...OptionFunc) ([]*PipelineVariable, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/pipelines/%d/variables", url.QueryEscape(project), pipeline)
+
+	req, err := s.client.NewRequest("GET", u, nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var p []*PipelineVariable
+	resp, err := s.client.Do(req, &p)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return p, resp, err
+}
+
+// PipelineTestReport represents the test report of a pipeline.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/pipelines.html#get-a-pipelines-test-report
+type PipelineTestReport struct {
+	TotalTime    float64               `json:"total_time"`
+	TotalCount   int                   `json:"total_count"`
+	SuccessCount int                   `json:"success_count"`
+	FailedCount  int                   `json:"failed_count"`
+	SkippedCount int                   `json:"skipped_count"`
+	ErrorCount   int                   `json:"error_count"`
+	TestSuites   []*PipelineTestSuites `json:"test_suites"`
+}
+
+// PipelineTestSuites represents a test suite of a pipeline test report.
+type PipelineTestSuites struct {
+	Name         string               `json:"name"`
+	TotalTime    float64              `json:"total_time"`
+	TotalCount   int                  `json:"total_count"`
+	SuccessCount int                  `json:"success_count"`
+	FailedCount  int                  `json:"failed_count"`
+	SkippedCount int                  `json:"skipped_count"`
+	ErrorCount   int                  `json:"error_count"`
+	BuildIDs     []int                `json:"build_ids"`
+	SuiteError   string               `json:"suite_error"`
+	TestCases    []*PipelineTestCases `json:"test_cases"`
+}
+
+// PipelineTestCases represents a test case of a pipeline test suite.
+type PipelineTestCases struct {
+	Status         string  `json:"status"`
+	Name           string  `json:"name"`
+	Classname      string  `json:"classname"`
+	File           string  `json:"file"`
+	ExecutionTime  float64 `json:"execution_time"`
+	SystemOutput   string  `json:"system_output"`
+	StackTrace     string  `json:"stack_trace"`
+	AttachmentURL  string  `json:"attachment_url"`
+	RecentFailures *struct {
+		Count      int    `json:"count"`
+		BaseBranch string `json:"base_branch"`
+	} `json:"recent_failures"`
+}
+
+// Suite returns the test suite with the given name, or nil if there is no
+// such suite.
+func (r *PipelineTestReport) Suite(name string) *PipelineTestSuites {
+	for _, s := range r.TestSuites {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// FailedTestCases returns the failed test cases and the test cases with
+// errors of all suites.
+func (r *PipelineTestReport) FailedTestCases() []*PipelineTestCases {
+	var failed []*PipelineTestCases
+	for _, s := range r.TestSuites {
+		for _, c := range s.TestCases {
+			if c.Status == "failed" || c.Status == "error" {
+				failed = append(failed, c)
+			}
+		}
+	}
+	return failed
+}
+
+// GetPipelineTestReport gets the test report of a single project pipeline,
+// including the test cases of all suites.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/pipelines.html#get-a-pipelines-test-report
+func (s *PipelinesService) GetPipelineTestReport(pid interface{}, pipeline int, options ...OptionFunc) (*PipelineTestReport, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/pipelines/%d/test_report", url.QueryEscape(project), pipeline)
+
+	req, err := s.client.NewRequest("GET", u, nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := new(PipelineTestReport)
+	resp, err := s.client.Do(req, p)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return p, resp, err
+}
+
+// PipelineTestReportSummary represents the summary of the test report of a
+// pipeline.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/pipelines.html#get-a-pipelines-test-report-summary
+type PipelineTestReportSummary struct {
+	Total struct {
+		Time       float64 `json:"time"`
+		Count      int     `json:"count"`
+		Success    int     `json:"success"`
+		Failed     int     `json:"failed"`
+		Skipped    int     `json:"skipped"`
+		Error      int     `json:"error"`
+		SuiteError string  `json:"suite_error"`
+	} `json:"total"`
+	TestSuites []*PipelineTestSuites `json:"test_suites"`
+}
+
+// GetPipelineTestReportSummary gets the summary of the test report of a
+// single project pipeline. The suites of the summary have no test cases.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/pipelines.html#get-a-pipelines-test-report-summary
+func (s *PipelinesService) GetPipelineTestReportSummary(pid interface{}, pipeline int, options ...OptionFunc) (*PipelineTestReportSummary, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/pipelines/%d/test_report_summary", url.QueryEscape(project), pipeline)
+
+	req, err := s.client.NewRequest("GET", u, nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := new(PipelineTestReportSummary)
+	resp, err := s.client.Do(req, p)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return p, resp, err
+}
@@ -109,3 +109,60 @@ func TestCancelPipelineBuild(t *testing.T) {
 		t.Errorf("Pipelines.CancelPipelineBuild returned %+v, want %+v", pipeline, want)
 	}
 }
+
+func TestGetPipelineTestReport(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/2/test_report", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{
+			"total_count": 2,
+			"failed_count": 1,
+			"test_suites": [{
+				"name": "rspec",
+				"total_count": 2,
+				"build_ids": [3],
+				"test_cases": [
+					{"status": "success", "name": "works"},
+					{"status": "failed", "name": "breaks", "stack_trace": "boom"}
+				]
+			}]
+		}`)
+	})
+
+	report, _, err := client.Pipelines.GetPipelineTestReport(1, 2)
+	if err != nil {
+		t.Fatalf("Pipelines.GetPipelineTestReport returned error: %v", err)
+	}
+
+	suite := report.Suite("rspec")
+	if suite == nil || len(suite.TestCases) != 2 || !reflect.DeepEqual([]int{3}, suite.BuildIDs) {
+		t.Fatalf("Pipelines.GetPipelineTestReport returned suite %+v", suite)
+	}
+
+	want := []*PipelineTestCases{{Status: "failed", Name: "breaks", StackTrace: "boom"}}
+	if failed := report.FailedTestCases(); !reflect.DeepEqual(want, failed) {
+		t.Errorf("PipelineTestReport.FailedTestCases returned %+v, want %+v", failed, want)
+	}
+}
+
+func TestGetPipelineVariables(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/2/variables", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"key":"RUN_NIGHTLY_BUILD","value":"true"}]`)
+	})
+
+	variables, _, err := client.Pipelines.GetPipelineVariables(1, 2)
+	if err != nil {
+		t.Fatalf("Pipelines.GetPipelineVariables returned error: %v", err)
+	}
+
+	want := []*PipelineVariable{{Key: "RUN_NIGHTLY_BUILD", Value: "true"}}
+	if !reflect.DeepEqual(want, variables) {
+		t.Errorf("Pipelines.GetPipelineVariables returned %+v, want %+v", variables, want)
+	}
+}
@@ -35,28 +35,37 @@ type ProjectVariablesService struct {
 // GitLab API docs:
 // https://docs.gitlab.com/ee/api/project_level_variables.html
 type ProjectVariable struct {
-	Key              string `json:"key"`
-	Value            string `json:"value"`
-	Protected        bool   `json:"protected"`
-	EnvironmentScope string `json:"environment_scope"`
+	Key              string            `json:"key"`
+	Value            string            `json:"value"`
+	VariableType     VariableTypeValue `json:"variable_type"`
+	Protected        bool              `json:"protected"`
+	Masked           bool              `json:"masked"`
+	EnvironmentScope string            `json:"environment_scope"`
 }
 
 func (v ProjectVariable) String() string {
 	return Stringify(v)
 }
 
+// ListProjectVariablesOptions represents the available ListVariables()
+// options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ee/api/project_level_variables.html#list-project-variables
+type ListProjectVariablesOptions ListOptions
+
 // ListVariables gets a list of all variables in a project.
 //
 // GitLab API docs:
 // https://docs.gitlab.com/ee/api/project_level_variables.html#list-project-variables
-func (s *ProjectVariablesService) ListVariables(pid interface{}, options ...OptionFunc) ([]*ProjectVariable, *Response, error) {
+func (s *ProjectVariablesService) ListVariables(pid interface{}, opt *ListProjectVariablesOptions, options ...OptionFunc) ([]*ProjectVariable, *Response, error) {
 	project, err := parseID(pid)
 	if err != nil {
 		return nil, nil, err
 	}
 	u := fmt.Sprintf("projects/%s/variables", url.QueryEscape(project))
 
-	req, err := s.client.NewRequest("GET", u, nil, options)
+	req, err := s.client.NewRequest("GET", u, opt, options)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -101,10 +110,12 @@ func (s *ProjectVariablesService) GetVariable(pid interface{}, key string, optio
 // GitLab API docs:
 // https://docs.gitlab.com/ee/api/project_level_variables.html#create-variable
 type CreateVariableOptions struct {
-	Key              *string `url:"key,omitempty" json:"key,omitempty"`
-	Value            *string `url:"value,omitempty" json:"value,omitempty"`
-	Protected        *bool   `url:"protected,omitempty" json:"protected,omitempty"`
-	EnvironmentScope *string `url:"environment_scope,omitempty" json:"environment_scope,omitempty"`
+	Key              *string            `url:"key,omitempty" json:"key,omitempty"`
+	Value            *string            `url:"value,omitempty" json:"value,omitempty"`
+	VariableType     *VariableTypeValue `url:"variable_type,omitempty" json:"variable_type,omitempty"`
+	Protected        *bool              `url:"protected,omitempty" json:"protected,omitempty"`
+	Masked           *bool              `url:"masked,omitempty" json:"masked,omitempty"`
+	EnvironmentScope *string            `url:"environment_scope,omitempty" json:"environment_scope,omitempty"`
 }
 
 // CreateVariable creates a new project variable.
@@ -138,9 +149,21 @@ func (s *ProjectVariablesService) CreateVariable(pid interface{}, opt *CreateVar
 // GitLab API docs:
 // https://docs.gitlab.com/ee/api/project_level_variables.html#update-variable
 type UpdateVariableOptions struct {
-	Value            *string `url:"value,omitempty" json:"value,omitempty"`
-	Protected        *bool   `url:"protected,omitempty" json:"protected,omitempty"`
-	EnvironmentScope *string `url:"environment_scope,omitempty" json:"environment_scope,omitempty"`
+	Value            *string            `url:"value,omitempty" json:"value,omitempty"`
+	VariableType     *VariableTypeValue `url:"variable_type,omitempty" json:"variable_type,omitempty"`
+	Protected        *bool              `url:"protected,omitempty" json:"protected,omitempty"`
+	Masked           *bool              `url:"masked,omitempty" json:"masked,omitempty"`
+	EnvironmentScope *string            `url:"environment_scope,omitempty" json:"environment_scope,omitempty"`
+	Filter           *VariableFilter    `url:"filter,omitempty" json:"filter,omitempty"`
+}
+
+// VariableFilter selects the variable to update or remove when a project
+// has multiple variables with the same key in different environment scopes.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ee/api/project_level_variables.html#the-filter-parameter
+type VariableFilter struct {
+	EnvironmentScope string `url:"environment_scope,omitempty" json:"environment_scope,omitempty"`
 }
 
 // UpdateVariable updates a project's variable
@@ -171,11 +194,20 @@ func (s *ProjectVariablesService) UpdateVariable(pid interface{}, key string, op
 	return v, resp, err
 }
 
+// RemoveProjectVariableOptions represents the available RemoveVariable()
+// options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ee/api/project_level_variables.html#remove-variable
+type RemoveProjectVariableOptions struct {
+	Filter *VariableFilter `url:"filter,omitempty" json:"filter,omitempty"`
+}
+
 // RemoveVariable removes a project's variable.
 //
 // GitLab API docs:
 // https://docs.gitlab.com/ee/api/project_level_variables.html#remove-variable
-func (s *ProjectVariablesService) RemoveVariable(pid interface{}, key string, options ...OptionFunc) (*Response, error) {
+func (s *ProjectVariablesService) RemoveVariable(pid interface{}, key string, opt *RemoveProjectVariableOptions, options ...OptionFunc) (*Response, error) {
 	project, err := parseID(pid)
 	if err != nil {
 		return nil, err
@@ -185,7 +217,7 @@ func (s *ProjectVariablesService) RemoveVariable(pid interface{}, key string, op
 		url.QueryEscape(key),
 	)
 
-	req, err := s.client.NewRequest("DELETE", u, nil, options)
+	req, err := s.client.NewRequest("DELETE", u, opt, options)
 	if err != nil {
 		return nil, err
 	}
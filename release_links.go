@@ -0,0 +1,295 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// ReleaseLinksService handles communication with the release link methods
+// of the GitLab API.
+//
+// GitLab API docs: https://docs.gitlab.com/ce/api/releases/links.html
+type ReleaseLinksService struct {
+	client *Client
+}
+
+// ReleaseLinkTypeValue represents the type of a release link.
+type ReleaseLinkTypeValue string
+
+// List of available release link types.
+const (
+	OtherReleaseLink   ReleaseLinkTypeValue = "other"
+	RunbookReleaseLink ReleaseLinkTypeValue = "runbook"
+	ImageReleaseLink   ReleaseLinkTypeValue = "image"
+	PackageReleaseLink ReleaseLinkTypeValue = "package"
+)
+
+// ReleaseLinkType is a helper routine that allocates a new
+// ReleaseLinkTypeValue to store v and returns a pointer to it.
+func ReleaseLinkType(v ReleaseLinkTypeValue) *ReleaseLinkTypeValue {
+	p := new(ReleaseLinkTypeValue)
+	*p = v
+	return p
+}
+
+// ReleaseLink represents an asset link of a release.
+//
+// GitLab API docs: https://docs.gitlab.com/ce/api/releases/links.html
+type ReleaseLink struct {
+	ID             int                  `json:"id"`
+	Name           string               `json:"name"`
+	URL            string               `json:"url"`
+	DirectAssetURL string               `json:"direct_asset_url"`
+	External       bool                 `json:"external"`
+	LinkType       ReleaseLinkTypeValue `json:"link_type"`
+}
+
+func (l ReleaseLink) String() string {
+	return Stringify(l)
+}
+
+// ListReleaseLinksOptions represents the available ListReleaseLinks() options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/links.html#get-links
+type ListReleaseLinksOptions ListOptions
+
+// ListReleaseLinks gets the asset links of a release.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/links.html#get-links
+func (s *ReleaseLinksService) ListReleaseLinks(pid interface{}, tagName string, opt *ListReleaseLinksOptions, options ...OptionFunc) ([]*ReleaseLink, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases/%s/assets/links", url.QueryEscape(project), url.QueryEscape(tagName))
+
+	req, err := s.client.NewRequest("GET", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var l []*ReleaseLink
+	resp, err := s.client.Do(req, &l)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return l, resp, err
+}
+
+// GetReleaseLink gets an asset link of a release.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/links.html#get-a-link
+func (s *ReleaseLinksService) GetReleaseLink(pid interface{}, tagName string, link int, options ...OptionFunc) (*ReleaseLink, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases/%s/assets/links/%d", url.QueryEscape(project), url.QueryEscape(tagName), link)
+
+	req, err := s.client.NewRequest("GET", u, nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := new(ReleaseLink)
+	resp, err := s.client.Do(req, l)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return l, resp, err
+}
+
+// CreateReleaseLinkOptions represents the available CreateReleaseLink() options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/links.html#create-a-link
+type CreateReleaseLinkOptions struct {
+	Name     *string               `url:"name,omitempty" json:"name,omitempty"`
+	URL      *string               `url:"url,omitempty" json:"url,omitempty"`
+	Filepath *string               `url:"filepath,omitempty" json:"filepath,omitempty"`
+	LinkType *ReleaseLinkTypeValue `url:"link_type,omitempty" json:"link_type,omitempty"`
+}
+
+// CreateReleaseLink creates an asset link for a release.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/links.html#create-a-link
+func (s *ReleaseLinksService) CreateReleaseLink(pid interface{}, tagName string, opt *CreateReleaseLinkOptions, options ...OptionFunc) (*ReleaseLink, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases/%s/assets/links", url.QueryEscape(project), url.QueryEscape(tagName))
+
+	req, err := s.client.NewRequest("POST", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := new(ReleaseLink)
+	resp, err := s.client.Do(req, l)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return l, resp, err
+}
+
+// UpdateReleaseLinkOptions represents the available UpdateReleaseLink() options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/links.html#update-a-link
+type UpdateReleaseLinkOptions struct {
+	Name     *string               `url:"name,omitempty" json:"name,omitempty"`
+	URL      *string               `url:"url,omitempty" json:"url,omitempty"`
+	Filepath *string               `url:"filepath,omitempty" json:"filepath,omitempty"`
+	LinkType *ReleaseLinkTypeValue `url:"link_type,omitempty" json:"link_type,omitempty"`
+}
+
+// UpdateReleaseLink updates an asset link of a release.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/links.html#update-a-link
+func (s *ReleaseLinksService) UpdateReleaseLink(pid interface{}, tagName string, link int, opt *UpdateReleaseLinkOptions, options ...OptionFunc) (*ReleaseLink, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases/%s/assets/links/%d", url.QueryEscape(project), url.QueryEscape(tagName), link)
+
+	req, err := s.client.NewRequest("PUT", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := new(ReleaseLink)
+	resp, err := s.client.Do(req, l)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return l, resp, err
+}
+
+// DeleteReleaseLink deletes an asset link of a release.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/links.html#delete-a-link
+func (s *ReleaseLinksService) DeleteReleaseLink(pid interface{}, tagName string, link int, options ...OptionFunc) (*ReleaseLink, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases/%s/assets/links/%d", url.QueryEscape(project), url.QueryEscape(tagName), link)
+
+	req, err := s.client.NewRequest("DELETE", u, nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := new(ReleaseLink)
+	resp, err := s.client.Do(req, l)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return l, resp, err
+}
+
+// UploadReleaseLinkOptions represents the available UploadReleaseLink()
+// options.
+type UploadReleaseLinkOptions struct {
+	// Name is the name of the link. Defaults to the base name of the file.
+	Name *string
+
+	Filepath *string
+	LinkType *ReleaseLinkTypeValue
+}
+
+// UploadReleaseLink uploads a file through the project upload API and
+// attaches it to a release as an asset link. If the release already has a
+// link with the same name, that link is pointed to the new upload, so that
+// a release pipeline can safely be retried.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/projects.html#upload-a-file
+func (s *ReleaseLinksService) UploadReleaseLink(pid interface{}, tagName, file string, opt *UploadReleaseLinkOptions, options ...OptionFunc) (*ReleaseLink, error) {
+	if opt == nil {
+		opt = &UploadReleaseLinkOptions{}
+	}
+	name := filepath.Base(file)
+	if opt.Name != nil {
+		name = *opt.Name
+	}
+
+	// Look up the existing links first, which also fails for a missing release
+	// before anything is uploaded.
+	var existing *ReleaseLink
+	lopt := &ListReleaseLinksOptions{Page: 1, PerPage: 100}
+	for {
+		links, resp, err := s.ListReleaseLinks(pid, tagName, lopt, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range links {
+			if l.Name == name {
+				existing = l
+			}
+		}
+		if existing != nil || resp.NextPage == 0 {
+			break
+		}
+		lopt.Page = resp.NextPage
+	}
+
+	// Uploads are referenced relative to the web URL of the project.
+	p, _, err := s.client.Projects.GetProject(pid, nil, options...)
+	if err != nil {
+		return nil, err
+	}
+	uf, _, err := s.client.Projects.UploadFile(pid, file, options...)
+	if err != nil {
+		return nil, err
+	}
+	u := strings.TrimSuffix(p.WebURL, "/") + uf.URL
+
+	if existing != nil {
+		l, _, err := s.UpdateReleaseLink(pid, tagName, existing.ID, &UpdateReleaseLinkOptions{
+			URL:      String(u),
+			Filepath: opt.Filepath,
+			LinkType: opt.LinkType,
+		}, options...)
+		return l, err
+	}
+
+	l, _, err := s.CreateReleaseLink(pid, tagName, &CreateReleaseLinkOptions{
+		Name:     String(name),
+		URL:      String(u),
+		Filepath: opt.Filepath,
+		LinkType: opt.LinkType,
+	}, options...)
+	return l, err
+}
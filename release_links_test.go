@@ -0,0 +1,118 @@
+package gitlab
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCreateReleaseLink(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/releases/v1.0/assets/links", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testBody(t, r, `{"name":"linux-amd64","url":"https://example.com/bin","filepath":"/bin/app","link_type":"package"}`)
+		fmt.Fprint(w, `{"id": 2, "name": "linux-amd64", "url": "https://example.com/bin", "direct_asset_url": "https://example.com/-/releases/v1.0/downloads/bin/app", "link_type": "package"}`)
+	})
+
+	link, _, err := client.ReleaseLinks.CreateReleaseLink(1, "v1.0", &CreateReleaseLinkOptions{
+		Name:     String("linux-amd64"),
+		URL:      String("https://example.com/bin"),
+		Filepath: String("/bin/app"),
+		LinkType: ReleaseLinkType(PackageReleaseLink),
+	})
+	if err != nil {
+		t.Fatalf("ReleaseLinks.CreateReleaseLink returned error: %v", err)
+	}
+
+	want := &ReleaseLink{
+		ID:             2,
+		Name:           "linux-amd64",
+		URL:            "https://example.com/bin",
+		DirectAssetURL: "https://example.com/-/releases/v1.0/downloads/bin/app",
+		LinkType:       PackageReleaseLink,
+	}
+	if !reflect.DeepEqual(want, link) {
+		t.Errorf("ReleaseLinks.CreateReleaseLink returned %+v, want %+v", link, want)
+	}
+}
+
+func TestDeleteReleaseLink(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/releases/v1.0/assets/links/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		fmt.Fprint(w, `{"id": 2, "name": "linux-amd64"}`)
+	})
+
+	link, _, err := client.ReleaseLinks.DeleteReleaseLink(1, "v1.0", 2)
+	if err != nil || link.ID != 2 {
+		t.Errorf("ReleaseLinks.DeleteReleaseLink returned %+v, %v", link, err)
+	}
+}
+
+func TestUploadReleaseLink(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	dir, err := ioutil.TempDir("", "release-links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.tar.gz")
+	if err := ioutil.WriteFile(file, []byte("binary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mux.HandleFunc("/api/v4/projects/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id": 1, "web_url": "https://gitlab.example.com/group/app"}`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/uploads", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"alt": "app.tar.gz", "url": "/uploads/abc/app.tar.gz"}`)
+	})
+
+	var created, updated int
+	mux.HandleFunc("/api/v4/projects/1/releases/v1.0/assets/links", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `[{"id": 7, "name": "app.tar.gz", "url": "https://gitlab.example.com/group/app/uploads/old/app.tar.gz"}]`)
+		case "POST":
+			created++
+			testBody(t, r, `{"name":"checksums","url":"https://gitlab.example.com/group/app/uploads/abc/app.tar.gz"}`)
+			fmt.Fprint(w, `{"id": 8, "name": "checksums"}`)
+		}
+	})
+	mux.HandleFunc("/api/v4/projects/1/releases/v1.0/assets/links/7", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		updated++
+		testBody(t, r, `{"url":"https://gitlab.example.com/group/app/uploads/abc/app.tar.gz","link_type":"package"}`)
+		fmt.Fprint(w, `{"id": 7, "name": "app.tar.gz", "url": "https://gitlab.example.com/group/app/uploads/abc/app.tar.gz"}`)
+	})
+
+	link, err := client.ReleaseLinks.UploadReleaseLink(1, "v1.0", file, &UploadReleaseLinkOptions{
+		LinkType: ReleaseLinkType(PackageReleaseLink),
+	})
+	if err != nil {
+		t.Fatalf("ReleaseLinks.UploadReleaseLink returned error: %v", err)
+	}
+	if link.ID != 7 || updated != 1 || created != 0 {
+		t.Errorf("ReleaseLinks.UploadReleaseLink returned %+v, updated %d, created %d", link, updated, created)
+	}
+
+	link, err = client.ReleaseLinks.UploadReleaseLink(1, "v1.0", file, &UploadReleaseLinkOptions{Name: String("checksums")})
+	if err != nil {
+		t.Fatalf("ReleaseLinks.UploadReleaseLink returned error: %v", err)
+	}
+	if link.ID != 8 || created != 1 {
+		t.Errorf("ReleaseLinks.UploadReleaseLink returned %+v, created %d", link, created)
+	}
+}
@@ -0,0 +1,270 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ReleasesService handles communication with the releases methods
+// of the GitLab API.
+//
+// GitLab API docs: https://docs.gitlab.com/ce/api/releases/index.html
+type ReleasesService struct {
+	client *Client
+}
+
+// Release represents a GitLab version release.
+//
+// GitLab API docs: https://docs.gitlab.com/ce/api/releases/index.html
+type Release struct {
+	TagName         string             `json:"tag_name"`
+	Name            string             `json:"name"`
+	Description     string             `json:"description"`
+	DescriptionHTML string             `json:"description_html"`
+	CreatedAt       *time.Time         `json:"created_at"`
+	ReleasedAt      *time.Time         `json:"released_at"`
+	UpcomingRelease bool               `json:"upcoming_release"`
+	Author          *Author            `json:"author"`
+	Commit          *Commit            `json:"commit"`
+	Milestones      []*Milestone       `json:"milestones"`
+	Evidences       []*ReleaseEvidence `json:"evidences"`
+	Assets          *ReleaseAssets     `json:"assets"`
+}
+
+func (r Release) String() string {
+	return Stringify(r)
+}
+
+// ReleaseAssets represents the assets of a release: the generated source
+// archives and the asset links.
+type ReleaseAssets struct {
+	Count   int              `json:"count"`
+	Sources []*ReleaseSource `json:"sources"`
+	Links   []*ReleaseLink   `json:"links"`
+}
+
+// ReleaseSource represents a source archive of a release.
+type ReleaseSource struct {
+	Format string `json:"format"`
+	URL    string `json:"url"`
+}
+
+// ReleaseEvidence represents the evidence collected for a release.
+type ReleaseEvidence struct {
+	SHA         string     `json:"sha"`
+	Filepath    string     `json:"filepath"`
+	CollectedAt *time.Time `json:"collected_at"`
+}
+
+// ListReleasesOptions represents the available ListReleases() options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/index.html#list-releases
+type ListReleasesOptions struct {
+	ListOptions
+	OrderBy *string `url:"order_by,omitempty" json:"order_by,omitempty"`
+	Sort    *string `url:"sort,omitempty" json:"sort,omitempty"`
+}
+
+// ListReleases gets a paginated list of the releases of a project, sorted
+// by released_at.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/index.html#list-releases
+func (s *ReleasesService) ListReleases(pid interface{}, opt *ListReleasesOptions, options ...OptionFunc) ([]*Release, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases", url.QueryEscape(project))
+
+	req, err := s.client.NewRequest("GET", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var r []*Release
+	resp, err := s.client.Do(req, &r)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return r, resp, err
+}
+
+// GetRelease gets the release of a tag.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/index.html#get-a-release-by-a-tag-name
+func (s *ReleasesService) GetRelease(pid interface{}, tagName string, options ...OptionFunc) (*Release, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases/%s", url.QueryEscape(project), url.QueryEscape(tagName))
+
+	req, err := s.client.NewRequest("GET", u, nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := new(Release)
+	resp, err := s.client.Do(req, r)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return r, resp, err
+}
+
+// CreateReleaseRequestOptions represents the available CreateRelease()
+// options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/index.html#create-a-release
+type CreateReleaseRequestOptions struct {
+	Name        *string               `url:"name,omitempty" json:"name,omitempty"`
+	TagName     *string               `url:"tag_name,omitempty" json:"tag_name,omitempty"`
+	Description *string               `url:"description,omitempty" json:"description,omitempty"`
+	Ref         *string               `url:"ref,omitempty" json:"ref,omitempty"`
+	Milestones  []string              `url:"milestones,omitempty" json:"milestones,omitempty"`
+	Assets      *ReleaseAssetsOptions `url:"assets,omitempty" json:"assets,omitempty"`
+	ReleasedAt  *time.Time            `url:"released_at,omitempty" json:"released_at,omitempty"`
+}
+
+// ReleaseAssetsOptions represents the assets of a new release.
+type ReleaseAssetsOptions struct {
+	Links []*ReleaseAssetLinkOptions `url:"links,omitempty" json:"links,omitempty"`
+}
+
+// ReleaseAssetLinkOptions represents an asset link of a new release.
+type ReleaseAssetLinkOptions struct {
+	Name     *string               `url:"name,omitempty" json:"name,omitempty"`
+	URL      *string               `url:"url,omitempty" json:"url,omitempty"`
+	Filepath *string               `url:"filepath,omitempty" json:"filepath,omitempty"`
+	LinkType *ReleaseLinkTypeValue `url:"link_type,omitempty" json:"link_type,omitempty"`
+}
+
+// CreateRelease creates a release. If the tag doesn't exist yet, it is
+// created from Ref.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/index.html#create-a-release
+func (s *ReleasesService) CreateRelease(pid interface{}, opt *CreateReleaseRequestOptions, options ...OptionFunc) (*Release, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases", url.QueryEscape(project))
+
+	req, err := s.client.NewRequest("POST", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := new(Release)
+	resp, err := s.client.Do(req, r)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return r, resp, err
+}
+
+// UpdateReleaseRequestOptions represents the available UpdateRelease()
+// options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/index.html#update-a-release
+type UpdateReleaseRequestOptions struct {
+	Name        *string    `url:"name,omitempty" json:"name,omitempty"`
+	Description *string    `url:"description,omitempty" json:"description,omitempty"`
+	Milestones  []string   `url:"milestones,omitempty" json:"milestones,omitempty"`
+	ReleasedAt  *time.Time `url:"released_at,omitempty" json:"released_at,omitempty"`
+}
+
+// UpdateRelease updates a release.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/index.html#update-a-release
+func (s *ReleasesService) UpdateRelease(pid interface{}, tagName string, opt *UpdateReleaseRequestOptions, options ...OptionFunc) (*Release, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases/%s", url.QueryEscape(project), url.QueryEscape(tagName))
+
+	req, err := s.client.NewRequest("PUT", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := new(Release)
+	resp, err := s.client.Do(req, r)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return r, resp, err
+}
+
+// DeleteRelease deletes a release. The tag itself is kept.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/index.html#delete-a-release
+func (s *ReleasesService) DeleteRelease(pid interface{}, tagName string, options ...OptionFunc) (*Release, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases/%s", url.QueryEscape(project), url.QueryEscape(tagName))
+
+	req, err := s.client.NewRequest("DELETE", u, nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := new(Release)
+	resp, err := s.client.Do(req, r)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return r, resp, err
+}
+
+// CollectReleaseEvidence collects new evidence for an existing release.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/releases/index.html#collect-release-evidence
+func (s *ReleasesService) CollectReleaseEvidence(pid interface{}, tagName string, options ...OptionFunc) (*Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, err
+	}
+	u := fmt.Sprintf("projects/%s/releases/%s/evidence", url.QueryEscape(project), url.QueryEscape(tagName))
+
+	req, err := s.client.NewRequest("POST", u, nil, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.client.Do(req, nil)
+}
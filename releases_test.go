@@ -0,0 +1,126 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestListReleases(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/releases", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"tag_name": "v1.1", "name": "Release 1.1"},{"tag_name": "v1.0", "name": "Release 1.0"}]`)
+	})
+
+	releases, _, err := client.Releases.ListReleases(1, nil)
+	if err != nil {
+		t.Errorf("Releases.ListReleases returned error: %v", err)
+	}
+
+	want := []*Release{{TagName: "v1.1", Name: "Release 1.1"}, {TagName: "v1.0", Name: "Release 1.0"}}
+	if !reflect.DeepEqual(want, releases) {
+		t.Errorf("Releases.ListReleases returned %+v, want %+v", releases, want)
+	}
+}
+
+func TestGetRelease(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/releases/v1.0", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{
+			"tag_name": "v1.0",
+			"name": "Release 1.0",
+			"milestones": [{"id": 3, "title": "v1.0"}],
+			"evidences": [{"sha": "760d6cdfb0879c3ffedec13af470e0f71cf52c6cde4d", "filepath": "https://example.com/evidence.json"}],
+			"assets": {
+				"count": 2,
+				"sources": [{"format": "zip", "url": "https://example.com/archive.zip"}],
+				"links": [{"id": 1, "name": "linux-amd64", "url": "https://example.com/bin", "link_type": "package"}]
+			}
+		}`)
+	})
+
+	release, _, err := client.Releases.GetRelease(1, "v1.0")
+	if err != nil {
+		t.Fatalf("Releases.GetRelease returned error: %v", err)
+	}
+
+	if release.Name != "Release 1.0" || len(release.Milestones) != 1 || release.Milestones[0].Title != "v1.0" {
+		t.Errorf("Releases.GetRelease returned %+v", release)
+	}
+	if len(release.Evidences) != 1 || release.Assets == nil || release.Assets.Count != 2 || len(release.Assets.Sources) != 1 {
+		t.Errorf("Releases.GetRelease returned %+v", release)
+	}
+	want := &ReleaseLink{ID: 1, Name: "linux-amd64", URL: "https://example.com/bin", LinkType: PackageReleaseLink}
+	if !reflect.DeepEqual(want, release.Assets.Links[0]) {
+		t.Errorf("Releases.GetRelease returned link %+v, want %+v", release.Assets.Links[0], want)
+	}
+}
+
+func TestCreateProjectRelease(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/releases", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testBody(t, r, `{"name":"Release 1.0","tag_name":"v1.0","ref":"master","milestones":["v1.0"],"assets":{"links":[{"name":"docs","url":"https://example.com/docs","link_type":"runbook"}]}}`)
+		fmt.Fprint(w, `{"tag_name": "v1.0", "name": "Release 1.0"}`)
+	})
+
+	opt := &CreateReleaseRequestOptions{
+		Name:       String("Release 1.0"),
+		TagName:    String("v1.0"),
+		Ref:        String("master"),
+		Milestones: []string{"v1.0"},
+		Assets: &ReleaseAssetsOptions{
+			Links: []*ReleaseAssetLinkOptions{{
+				Name:     String("docs"),
+				URL:      String("https://example.com/docs"),
+				LinkType: ReleaseLinkType(RunbookReleaseLink),
+			}},
+		},
+	}
+
+	release, _, err := client.Releases.CreateRelease(1, opt)
+	if err != nil {
+		t.Fatalf("Releases.CreateRelease returned error: %v", err)
+	}
+
+	want := &Release{TagName: "v1.0", Name: "Release 1.0"}
+	if !reflect.DeepEqual(want, release) {
+		t.Errorf("Releases.CreateRelease returned %+v, want %+v", release, want)
+	}
+}
+
+func TestUpdateAndDeleteProjectRelease(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/releases/v1.0", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			testBody(t, r, `{"name":"Final release"}`)
+			fmt.Fprint(w, `{"tag_name": "v1.0", "name": "Final release"}`)
+		case "DELETE":
+			fmt.Fprint(w, `{"tag_name": "v1.0", "name": "Final release"}`)
+		default:
+			t.Errorf("Request method: %s", r.Method)
+		}
+	})
+
+	release, _, err := client.Releases.UpdateRelease(1, "v1.0", &UpdateReleaseRequestOptions{Name: String("Final release")})
+	if err != nil || release.Name != "Final release" {
+		t.Errorf("Releases.UpdateRelease returned %+v, %v", release, err)
+	}
+
+	release, _, err = client.Releases.DeleteRelease(1, "v1.0")
+	if err != nil || release.TagName != "v1.0" {
+		t.Errorf("Releases.DeleteRelease returned %+v, %v", release, err)
+	}
+}
@@ -19,6 +19,7 @@ package gitlab
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net/url"
 )
 
@@ -137,7 +138,9 @@ func (s *RepositoriesService) RawBlobContent(pid interface{}, sha string, option
 // GitLab API docs:
 // https://docs.gitlab.com/ce/api/repositories.html#get-file-archive
 type ArchiveOptions struct {
-	SHA *string `url:"sha,omitempty" json:"sha,omitempty"`
+	Format *string `url:"-" json:"-"`
+	Path   *string `url:"path,omitempty" json:"path,omitempty"`
+	SHA    *string `url:"sha,omitempty" json:"sha,omitempty"`
 }
 
 // Archive gets an archive of the repository.
@@ -145,24 +148,39 @@ type ArchiveOptions struct {
 // GitLab API docs:
 // https://docs.gitlab.com/ce/api/repositories.html#get-file-archive
 func (s *RepositoriesService) Archive(pid interface{}, opt *ArchiveOptions, options ...OptionFunc) ([]byte, *Response, error) {
+	var b bytes.Buffer
+	resp, err := s.StreamArchive(pid, &b, opt, options...)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return b.Bytes(), resp, err
+}
+
+// StreamArchive streams an archive of the repository to the provided
+// io.Writer.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/repositories.html#get-file-archive
+func (s *RepositoriesService) StreamArchive(pid interface{}, w io.Writer, opt *ArchiveOptions, options ...OptionFunc) (*Response, error) {
 	project, err := parseID(pid)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	u := fmt.Sprintf("projects/%s/repository/archive", url.QueryEscape(project))
 
-	req, err := s.client.NewRequest("GET", u, opt, options)
-	if err != nil {
-		return nil, nil, err
+	// Set format to be one of the supported formats: tar.gz, tar.bz2, tbz,
+	// tbz2, tb2, bz2, tar, and zip.
+	if opt != nil && opt.Format != nil {
+		u = fmt.Sprintf("%s.%s", u, *opt.Format)
 	}
 
-	var b bytes.Buffer
-	resp, err := s.client.Do(req, &b)
+	req, err := s.client.NewRequest("GET", u, opt, options)
 	if err != nil {
-		return nil, resp, err
+		return nil, err
 	}
 
-	return b.Bytes(), resp, err
+	return s.client.Do(req, w)
 }
 
 // Compare represents the result of a comparison of branches, tags or commits.
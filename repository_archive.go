@@ -0,0 +1,408 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrArchiveTooLarge is returned when extracting a repository archive would
+// exceed the configured size limits.
+var ErrArchiveTooLarge = errors.New("archive exceeds size limit")
+
+// ArchiveSymlinkPolicyValue represents how symbolic links in a repository
+// archive are extracted.
+type ArchiveSymlinkPolicyValue string
+
+// List of available symlink policies.
+const (
+	// SkipArchiveSymlinks doesn't extract symbolic links.
+	SkipArchiveSymlinks ArchiveSymlinkPolicyValue = "skip"
+
+	// SafeArchiveSymlinks extracts symbolic links that point to a location
+	// inside the target directory, and skips all others.
+	SafeArchiveSymlinks ArchiveSymlinkPolicyValue = "safe"
+
+	// RejectArchiveSymlinks fails the extraction if the archive contains a
+	// symbolic link.
+	RejectArchiveSymlinks ArchiveSymlinkPolicyValue = "reject"
+)
+
+// ExtractArchiveOptions represents the available ExtractArchive() options.
+type ExtractArchiveOptions struct {
+	// Format is the archive format to download: tar.gz (the default),
+	// tar.bz2, tar or zip. Tar archives are extracted while they are
+	// downloaded, zip archives are downloaded to a temporary file first.
+	Format *string
+
+	// Path and SHA select the subdirectory and the commit, branch or tag
+	// to archive.
+	Path *string
+	SHA  *string
+
+	// StripComponents removes the given number of leading directories from
+	// the names of the entries. GitLab archives contain a single top level
+	// directory, which is removed with a value of 1.
+	StripComponents int
+
+	// Symlinks is the policy for symbolic links. Defaults to skipping them.
+	Symlinks ArchiveSymlinkPolicyValue
+
+	// MaxFileSize and MaxTotalSize limit the size of a single extracted
+	// file and of all extracted files together. Zero means no limit.
+	MaxFileSize  int64
+	MaxTotalSize int64
+}
+
+// ExtractArchive downloads an archive of the repository and extracts it into
+// the given directory. It returns the paths of the extracted files.
+//
+// Entries that would be written outside of the directory are rejected.
+// File permissions are taken from the archive, without setuid, setgid and
+// sticky bits and without write permissions for group and others. Entries
+// other than regular files, directories and symbolic links are skipped.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/repositories.html#get-file-archive
+func (s *RepositoriesService) ExtractArchive(pid interface{}, dir string, opt *ExtractArchiveOptions, options ...OptionFunc) ([]string, *Response, error) {
+	if opt == nil {
+		opt = &ExtractArchiveOptions{}
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	format := "tar.gz"
+	if opt.Format != nil {
+		format = *opt.Format
+	}
+	aopt := &ArchiveOptions{Format: String(format), Path: opt.Path, SHA: opt.SHA}
+	x := &archiveExtractor{dir: dir, realDir: realDir, opt: opt}
+
+	if format == "zip" {
+		f, err := ioutil.TempFile("", "gitlab-archive-")
+		if err != nil {
+			return nil, nil, err
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		resp, err := s.StreamArchive(pid, f, aopt, options...)
+		if err != nil {
+			return nil, resp, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return nil, resp, err
+		}
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return nil, resp, err
+		}
+		err = x.extractZip(zr)
+		return x.extracted, resp, err
+	}
+
+	// Stream the download straight into the tar reader.
+	pr, pw := io.Pipe()
+	done := make(chan *Response, 1)
+	go func() {
+		resp, err := s.StreamArchive(pid, pw, aopt, options...)
+		pw.CloseWithError(err)
+		done <- resp
+	}()
+
+	err = x.extractTar(pr, format)
+	// Unblock the download if the extraction stopped early.
+	pr.CloseWithError(err)
+	resp := <-done
+
+	return x.extracted, resp, err
+}
+
+type archiveExtractor struct {
+	dir       string
+	realDir   string
+	opt       *ExtractArchiveOptions
+	total     int64
+	extracted []string
+}
+
+func (x *archiveExtractor) extractTar(r io.Reader, format string) error {
+	switch format {
+	case "tar.gz", "tgz", "gz":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	case "tar.bz2", "tbz", "tbz2", "tb2", "bz2":
+		r = bzip2.NewReader(r)
+	case "tar":
+	default:
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var xerr error
+		switch h.Typeflag {
+		case tar.TypeReg:
+			xerr = x.file(h.Name, os.FileMode(h.Mode), h.Size, tr)
+		case tar.TypeDir:
+			xerr = x.mkdir(h.Name)
+		case tar.TypeSymlink:
+			xerr = x.symlink(h.Name, h.Linkname)
+		}
+		if xerr != nil {
+			return xerr
+		}
+	}
+}
+
+func (x *archiveExtractor) extractZip(zr *zip.Reader) error {
+	for _, f := range zr.File {
+		var err error
+		switch mode := f.Mode(); {
+		case mode.IsDir():
+			err = x.mkdir(f.Name)
+		case mode&os.ModeSymlink != 0:
+			err = x.zipSymlink(f)
+		case mode.IsRegular():
+			err = x.zipFile(f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *archiveExtractor) zipFile(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return x.file(f.Name, f.Mode(), int64(f.UncompressedSize64), rc)
+}
+
+func (x *archiveExtractor) zipSymlink(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	target, err := ioutil.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return err
+	}
+	return x.symlink(f.Name, string(target))
+}
+
+// target returns the path to extract an entry to, or "" if the entry is
+// removed completely by StripComponents.
+func (x *archiveExtractor) target(name string) (string, error) {
+	name = strings.TrimPrefix(name, "./")
+	if n := x.opt.StripComponents; n > 0 {
+		parts := strings.SplitN(strings.Trim(name, "/"), "/", n+1)
+		if len(parts) <= n {
+			return "", nil
+		}
+		name = parts[n]
+	}
+	name = strings.TrimSuffix(name, "/")
+	if name == "" || name == "." {
+		return "", nil
+	}
+	return safeExtractPath(x.dir, name)
+}
+
+func (x *archiveExtractor) mkdir(name string) error {
+	target, err := x.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	if err := x.checkInside(name, target); err != nil {
+		return err
+	}
+	return os.MkdirAll(target, 0755)
+}
+
+func (x *archiveExtractor) file(name string, mode os.FileMode, size int64, r io.Reader) error {
+	target, err := x.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+
+	limit, limited := extractLimit(x.opt.MaxFileSize, x.opt.MaxTotalSize, x.total)
+	if limited && (limit <= 0 || size > limit) {
+		return fmt.Errorf("%s: %v", name, ErrArchiveTooLarge)
+	}
+
+	if err := x.checkInside(name, filepath.Dir(target)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	// Never write through a symbolic link left by an earlier entry.
+	if fi, err := os.Lstat(target); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	n, err := writeExtractedFile(target, mode, r, limit, limited, ErrArchiveTooLarge)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	x.total += n
+	x.extracted = append(x.extracted, target)
+
+	return nil
+}
+
+func (x *archiveExtractor) symlink(name, link string) error {
+	switch x.opt.Symlinks {
+	case "", SkipArchiveSymlinks:
+		return nil
+	case RejectArchiveSymlinks:
+		return fmt.Errorf("%s: symbolic links are not allowed", name)
+	case SafeArchiveSymlinks:
+	default:
+		return fmt.Errorf("unknown symlink policy %q", x.opt.Symlinks)
+	}
+
+	target, err := x.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+
+	if path.IsAbs(link) || filepath.IsAbs(link) || strings.Contains(link, `\`) {
+		return nil
+	}
+
+	if err := x.checkInside(name, filepath.Dir(target)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	// Resolve the link from the real location of its directory, as that
+	// may itself be reached through a symbolic link.
+	parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	ok, err := x.inside(filepath.Join(parent, filepath.FromSlash(link)))
+	if err != nil || !ok {
+		return err
+	}
+
+	os.Remove(target)
+	if err := os.Symlink(link, target); err != nil {
+		return err
+	}
+	x.extracted = append(x.extracted, target)
+
+	return nil
+}
+
+// checkInside returns an error if p isn't inside the target directory once
+// symbolic links are resolved. This keeps entries from being written
+// through symbolic links extracted earlier.
+func (x *archiveExtractor) checkInside(name, p string) error {
+	ok, err := x.inside(p)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s: illegal path in archive", name)
+	}
+	return nil
+}
+
+// inside reports whether p resolves to a location inside the target
+// directory. Symbolic links are followed in the part of p that exists;
+// the rest can't contain any.
+func (x *archiveExtractor) inside(p string) (bool, error) {
+	existing := p
+	for {
+		_, err := os.Lstat(existing)
+		if err == nil {
+			break
+		}
+		if !os.IsNotExist(err) {
+			return false, err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return false, nil
+		}
+		existing = parent
+	}
+
+	real, err := filepath.EvalSymlinks(existing)
+	if os.IsNotExist(err) {
+		// A dangling symbolic link.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	rest, err := filepath.Rel(existing, p)
+	if err != nil {
+		return false, err
+	}
+	real = filepath.Join(real, rest)
+
+	return real == x.realDir || strings.HasPrefix(real, x.realDir+string(filepath.Separator)), nil
+}
@@ -0,0 +1,242 @@
+package gitlab
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testArchiveEntry struct {
+	name, content, link string
+	mode                int64
+	typ                 byte
+}
+
+func testTarGz(t *testing.T, entries []testArchiveEntry) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: e.mode, Typeflag: e.typ, Linkname: e.link, Size: int64(len(e.content))}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(e.content))
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestStreamArchive(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/archive.zip", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if q := r.URL.Query(); q.Get("sha") != "v1.0" || q.Get("path") != "docs" || q.Get("Format") != "" {
+			t.Errorf("Request query is %v", q)
+		}
+		w.Write([]byte("zip content"))
+	})
+
+	var buf bytes.Buffer
+	opt := &ArchiveOptions{Format: String("zip"), Path: String("docs"), SHA: String("v1.0")}
+	if _, err := client.Repositories.StreamArchive(1, &buf, opt); err != nil {
+		t.Fatalf("Repositories.StreamArchive returned error: %v", err)
+	}
+	if buf.String() != "zip content" {
+		t.Errorf("Repositories.StreamArchive returned %q", buf.String())
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	archive := testTarGz(t, []testArchiveEntry{
+		{name: "pax_global_header", typ: tar.TypeXGlobalHeader},
+		{name: "project-abc/", mode: 0755, typ: tar.TypeDir},
+		{name: "project-abc/README.md", content: "# Project", mode: 0666, typ: tar.TypeReg},
+		{name: "project-abc/bin/run.sh", content: "#!/bin/sh", mode: 04755, typ: tar.TypeReg},
+		{name: "project-abc/docs", link: "README.md", typ: tar.TypeSymlink},
+		{name: "project-abc/passwd", link: "../../etc/passwd", typ: tar.TypeSymlink},
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/archive.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Write(archive)
+	})
+
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opt := &ExtractArchiveOptions{StripComponents: 1, Symlinks: SafeArchiveSymlinks}
+	files, _, err := client.Repositories.ExtractArchive(1, dir, opt)
+	if err != nil {
+		t.Fatalf("Repositories.ExtractArchive returned error: %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("Repositories.ExtractArchive extracted %v", files)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "bin", "run.sh"))
+	if err != nil || info.Mode() != 0755 {
+		t.Errorf("run.sh has mode %v, %v", info.Mode(), err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "README.md")); err != nil || info.Mode() != 0644 {
+		t.Errorf("README.md has mode %v, %v", info.Mode(), err)
+	}
+	if link, err := os.Readlink(filepath.Join(dir, "docs")); err != nil || link != "README.md" {
+		t.Errorf("docs links to %q, %v", link, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "passwd")); !os.IsNotExist(err) {
+		t.Errorf("Unsafe symlink was extracted")
+	}
+
+	opt = &ExtractArchiveOptions{StripComponents: 1, Symlinks: RejectArchiveSymlinks}
+	if _, _, err := client.Repositories.ExtractArchive(1, dir, opt); err == nil || !strings.Contains(err.Error(), "symbolic links") {
+		t.Errorf("Repositories.ExtractArchive returned %v, want a symlink error", err)
+	}
+
+	opt = &ExtractArchiveOptions{MaxTotalSize: 12}
+	if _, _, err := client.Repositories.ExtractArchive(1, dir, opt); err == nil || !strings.Contains(err.Error(), ErrArchiveTooLarge.Error()) {
+		t.Errorf("Repositories.ExtractArchive returned %v, want %v", err, ErrArchiveTooLarge)
+	}
+}
+
+func TestExtractArchiveTraversal(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	archive := testTarGz(t, []testArchiveEntry{
+		{name: "project-abc/../../evil", content: "evil", mode: 0644, typ: tar.TypeReg},
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/archive.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+
+	var zbuf bytes.Buffer
+	zw := zip.NewWriter(&zbuf)
+	f, _ := zw.Create("../evil")
+	f.Write([]byte("evil"))
+	zw.Close()
+	mux.HandleFunc("/api/v4/projects/1/repository/archive.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(zbuf.Bytes())
+	})
+
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, format := range []string{"tar.gz", "zip"} {
+		_, _, err := client.Repositories.ExtractArchive(1, filepath.Join(dir, "out"), &ExtractArchiveOptions{Format: String(format)})
+		if err == nil || !strings.Contains(err.Error(), "illegal path") {
+			t.Errorf("Repositories.ExtractArchive(%s) returned %v, want an illegal path error", format, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil")); !os.IsNotExist(err) {
+		t.Errorf("File outside of the target directory was extracted")
+	}
+}
+
+func TestExtractArchiveSymlinkChain(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	// Lexically a/b -> .. stays inside the directory, but as a -> . it
+	// really is b -> .. and would let b/x escape.
+	archive := testTarGz(t, []testArchiveEntry{
+		{name: "project-abc/a", link: ".", typ: tar.TypeSymlink},
+		{name: "project-abc/a/b", link: "..", typ: tar.TypeSymlink},
+		{name: "project-abc/b/x", content: "evil", mode: 0644, typ: tar.TypeReg},
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/archive.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "out")
+	opt := &ExtractArchiveOptions{StripComponents: 1, Symlinks: SafeArchiveSymlinks}
+	if _, _, err := client.Repositories.ExtractArchive(1, out, opt); err != nil {
+		t.Fatalf("Repositories.ExtractArchive returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x")); !os.IsNotExist(err) {
+		t.Errorf("File outside of the target directory was extracted")
+	}
+	if fi, err := os.Lstat(filepath.Join(out, "b")); err != nil || fi.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("Repositories.ExtractArchive extracted b as %v, %v, want a directory", fi, err)
+	}
+
+	// A symbolic link that already points outside is never written through.
+	if err := os.Symlink(dir, filepath.Join(out, "up")); err != nil {
+		t.Fatal(err)
+	}
+	archive = testTarGz(t, []testArchiveEntry{
+		{name: "project-abc/up/y", content: "evil", mode: 0644, typ: tar.TypeReg},
+	})
+	_, _, err = client.Repositories.ExtractArchive(1, out, opt)
+	if err == nil || !strings.Contains(err.Error(), "illegal path") {
+		t.Errorf("Repositories.ExtractArchive returned %v, want an illegal path error", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "y")); !os.IsNotExist(err) {
+		t.Errorf("File outside of the target directory was extracted")
+	}
+}
+
+func TestExtractArchiveMaxTotalSize(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	archive := testTarGz(t, []testArchiveEntry{
+		{name: "a", content: "0123456789", mode: 0644, typ: tar.TypeReg},
+		{name: "b", content: "0123456789", mode: 0644, typ: tar.TypeReg},
+		{name: "c", content: "0123456789", mode: 0644, typ: tar.TypeReg},
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/archive.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+
+	for _, total := range []int64{10, 20} {
+		dir, err := ioutil.TempDir("", "archive")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		files, _, err := client.Repositories.ExtractArchive(1, dir, &ExtractArchiveOptions{MaxTotalSize: total})
+		if err == nil || !strings.Contains(err.Error(), ErrArchiveTooLarge.Error()) {
+			t.Errorf("Repositories.ExtractArchive returned %v, want %v", err, ErrArchiveTooLarge)
+		}
+		if int64(len(files))*10 != total {
+			t.Errorf("Repositories.ExtractArchive extracted %d files with a total limit of %d bytes", len(files), total)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files, _, err := client.Repositories.ExtractArchive(1, dir, &ExtractArchiveOptions{MaxTotalSize: 30})
+	if err != nil || len(files) != 3 {
+		t.Errorf("Repositories.ExtractArchive returned %v, %v, want 3 files", files, err)
+	}
+}
@@ -0,0 +1,102 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"sort"
+	"strings"
+)
+
+// BlameAuthor represents an author of a range of lines, together with the
+// commits in which the author last changed them.
+type BlameAuthor struct {
+	Name    string
+	Email   string
+	Lines   int
+	Commits []*Commit
+}
+
+// BlameAuthorsOptions represents the available BlameAuthors() options.
+type BlameAuthorsOptions struct {
+	Ref *string
+
+	// StartLine and EndLine select the lines to find the authors of,
+	// inclusive. Zero means the first and the last line of the file.
+	StartLine int
+	EndLine   int
+}
+
+// BlameAuthors returns the distinct authors that last changed the given
+// lines of a file, with the most lines first. Authors are identified by
+// their email address.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/repository_files.html#get-file-blame-from-repository
+func (s *RepositoryFilesService) BlameAuthors(pid interface{}, file string, opt *BlameAuthorsOptions, options ...OptionFunc) ([]*BlameAuthor, error) {
+	if opt == nil {
+		opt = &BlameAuthorsOptions{}
+	}
+
+	// The whole file is blamed and filtered here, as older GitLab versions
+	// ignore the range parameter.
+	ranges, _, err := s.GetFileBlame(pid, file, &GetFileBlameOptions{Ref: opt.Ref}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	var authors []*BlameAuthor
+	byKey := make(map[string]*BlameAuthor)
+	seen := make(map[string]bool)
+
+	for _, r := range ranges {
+		if r.Commit == nil {
+			continue
+		}
+
+		start, end := r.StartLine, r.EndLine()
+		if opt.StartLine > start {
+			start = opt.StartLine
+		}
+		if opt.EndLine > 0 && opt.EndLine < end {
+			end = opt.EndLine
+		}
+		if start > end {
+			continue
+		}
+
+		key := strings.ToLower(r.Commit.AuthorEmail)
+		if key == "" {
+			key = r.Commit.AuthorName
+		}
+		a, ok := byKey[key]
+		if !ok {
+			a = &BlameAuthor{Name: r.Commit.AuthorName, Email: r.Commit.AuthorEmail}
+			byKey[key] = a
+			authors = append(authors, a)
+		}
+
+		a.Lines += end - start + 1
+		if !seen[key+" "+r.Commit.ID] {
+			seen[key+" "+r.Commit.ID] = true
+			a.Commits = append(a.Commits, r.Commit)
+		}
+	}
+
+	sort.SliceStable(authors, func(i, j int) bool { return authors[i].Lines > authors[j].Lines })
+
+	return authors, nil
+}
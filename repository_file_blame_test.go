@@ -0,0 +1,44 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetFileBlame(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/files/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testURL(t, r, "/api/v4/projects/1/repository/files/src%2Fmain.go/blame?ref=master")
+		fmt.Fprint(w, `[
+			{"commit":{"id":"c1","author_name":"Alice","author_email":"alice@example.com"},"lines":["package main",""]},
+			{"commit":{"id":"c2","author_name":"Bob","author_email":"bob@example.com"},"lines":["import \"os\""]},
+			{"commit":{"id":"c3","author_name":"Alice","author_email":"Alice@example.com"},"lines":["","func main() {","}"]}
+		]`)
+	})
+
+	ranges, _, err := client.RepositoryFiles.GetFileBlame(1, "src/main.go", &GetFileBlameOptions{Ref: String("master")})
+	if err != nil {
+		t.Fatalf("RepositoryFiles.GetFileBlame returned error: %v", err)
+	}
+	if len(ranges) != 3 || ranges[1].StartLine != 3 || ranges[2].StartLine != 4 || ranges[2].EndLine() != 6 {
+		t.Errorf("RepositoryFiles.GetFileBlame returned %v", ranges)
+	}
+
+	authors, err := client.RepositoryFiles.BlameAuthors(1, "src/main.go", &BlameAuthorsOptions{Ref: String("master"), StartLine: 2, EndLine: 4})
+	if err != nil {
+		t.Fatalf("RepositoryFiles.BlameAuthors returned error: %v", err)
+	}
+	if len(authors) != 2 {
+		t.Fatalf("RepositoryFiles.BlameAuthors returned %d authors, want 2", len(authors))
+	}
+	if a := authors[0]; a.Name != "Alice" || a.Lines != 2 || len(a.Commits) != 2 {
+		t.Errorf("RepositoryFiles.BlameAuthors returned %+v", a)
+	}
+	if a := authors[1]; a.Name != "Bob" || a.Lines != 1 || a.Commits[0].ID != "c2" {
+		t.Errorf("RepositoryFiles.BlameAuthors returned %+v", a)
+	}
+}
@@ -0,0 +1,179 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FileConflictError is returned by ModifyFile if the file kept changing
+// between reading and writing it.
+type FileConflictError struct {
+	FilePath string
+	Branch   string
+	Attempts int
+
+	// Err is the error returned by GitLab for the last attempt.
+	Err error
+}
+
+func (e *FileConflictError) Error() string {
+	return fmt.Sprintf("file %s on branch %s changed concurrently, gave up after %d attempts", e.FilePath, e.Branch, e.Attempts)
+}
+
+// IsFileConflict reports whether err is returned by GitLab because a file
+// was changed since it was read, or was created since it was found to be
+// missing.
+func IsFileConflict(err error) bool {
+	if _, ok := err.(*FileConflictError); ok {
+		return true
+	}
+	e, ok := err.(*ErrorResponse)
+	if !ok || e.Response == nil {
+		return false
+	}
+	switch e.Response.StatusCode {
+	case http.StatusBadRequest, http.StatusConflict:
+		return strings.Contains(e.Message, "changed since") ||
+			strings.Contains(e.Message, "already exists")
+	}
+	return false
+}
+
+// ErrFileUnchanged can be returned by a FileTransform to leave the file as
+// it is.
+var ErrFileUnchanged = errors.New("file unchanged")
+
+// FileTransform returns the new content of a file. The content is nil if
+// the file doesn't exist yet.
+type FileTransform func(content []byte) ([]byte, error)
+
+// ModifyFileOptions represents the available ModifyFile() options.
+type ModifyFileOptions struct {
+	Branch        *string
+	CommitMessage *string
+	AuthorEmail   *string
+	AuthorName    *string
+
+	// MaxRetries is the number of times the file is read and transformed
+	// again after a conflict. Defaults to 3.
+	MaxRetries int
+}
+
+// ModifyFile reads a file, transforms it and writes it back, using the last
+// commit ID of the file to make sure no other changes are overwritten. If
+// the file changed in the meantime, it is read and transformed again, up to
+// MaxRetries times, after which a *FileConflictError is returned.
+//
+// Files that don't exist are created. No commit is made, and a nil FileInfo
+// is returned, if the transform returns the same content or
+// ErrFileUnchanged.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/repository_files.html#update-existing-file-in-repository
+func (s *RepositoryFilesService) ModifyFile(pid interface{}, fileName string, transform FileTransform, opt *ModifyFileOptions, options ...OptionFunc) (*FileInfo, *Response, error) {
+	if opt == nil || opt.Branch == nil {
+		return nil, nil, fmt.Errorf("a branch is required")
+	}
+
+	retries := opt.MaxRetries
+	if retries <= 0 {
+		retries = 3
+	}
+
+	var resp *Response
+	var err error
+	for attempt := 1; attempt <= retries+1; attempt++ {
+		var info *FileInfo
+		info, resp, err = s.modifyFile(pid, fileName, transform, opt, options)
+		if err == ErrFileUnchanged {
+			return nil, resp, nil
+		}
+		if !IsFileConflict(err) {
+			return info, resp, err
+		}
+	}
+
+	return nil, resp, &FileConflictError{
+		FilePath: fileName,
+		Branch:   *opt.Branch,
+		Attempts: retries + 1,
+		Err:      err,
+	}
+}
+
+func (s *RepositoryFilesService) modifyFile(pid interface{}, fileName string, transform FileTransform, opt *ModifyFileOptions, options []OptionFunc) (*FileInfo, *Response, error) {
+	f, resp, err := s.GetFile(pid, fileName, &GetFileOptions{Ref: opt.Branch}, options...)
+	if err != nil && !isNotFound(err) {
+		return nil, resp, err
+	}
+
+	var content []byte
+	if f != nil {
+		if content, err = f.decodedContent(); err != nil {
+			return nil, resp, err
+		}
+	}
+
+	updated, err := transform(content)
+	if err != nil {
+		return nil, resp, err
+	}
+	if f != nil && bytes.Equal(content, updated) {
+		return nil, resp, ErrFileUnchanged
+	}
+
+	var encoding *string
+	e, encoded := encodeContent(updated)
+	if e != "" {
+		encoding = String(e)
+	}
+
+	if f == nil {
+		return s.CreateFile(pid, fileName, &CreateFileOptions{
+			Branch:        opt.Branch,
+			Encoding:      encoding,
+			AuthorEmail:   opt.AuthorEmail,
+			AuthorName:    opt.AuthorName,
+			Content:       String(encoded),
+			CommitMessage: opt.CommitMessage,
+		}, options...)
+	}
+
+	return s.UpdateFile(pid, fileName, &UpdateFileOptions{
+		Branch:        opt.Branch,
+		Encoding:      encoding,
+		AuthorEmail:   opt.AuthorEmail,
+		AuthorName:    opt.AuthorName,
+		Content:       String(encoded),
+		CommitMessage: opt.CommitMessage,
+		LastCommitID:  String(f.LastCommitID),
+	}, options...)
+}
+
+// decodedContent returns the content of a file returned by GetFile.
+func (r *File) decodedContent() ([]byte, error) {
+	if r.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(r.Content)
+	}
+	return []byte(r.Content), nil
+}
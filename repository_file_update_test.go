@@ -0,0 +1,91 @@
+package gitlab
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestModifyFile(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	// The file is changed by someone else after the first read.
+	reads, writes := 0, 0
+	mux.HandleFunc("/api/v4/projects/1/repository/files/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			reads++
+			testURL(t, r, "/api/v4/projects/1/repository/files/config%2Fapp.yml?ref=master")
+			content := "replicas: 1\n"
+			if reads > 1 {
+				content = "replicas: 2\n"
+			}
+			fmt.Fprintf(w, `{"file_path":"config/app.yml","encoding":"base64","content":%q,"last_commit_id":"c%d"}`,
+				base64.StdEncoding.EncodeToString([]byte(content)), reads)
+		case "PUT":
+			writes++
+			testURL(t, r, "/api/v4/projects/1/repository/files/config%2Fapp.yml")
+			if writes == 1 {
+				testBody(t, r, `{"branch":"master","content":"replicas: 1\nimage: v2\n","commit_message":"Bump image","last_commit_id":"c1"}`)
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"message":"You are attempting to update a file that has changed since you started editing it."}`)
+				return
+			}
+			testBody(t, r, `{"branch":"master","content":"replicas: 2\nimage: v2\n","commit_message":"Bump image","last_commit_id":"c2"}`)
+			fmt.Fprint(w, `{"file_path":"config/app.yml","branch":"master"}`)
+		}
+	})
+
+	transform := func(content []byte) ([]byte, error) {
+		return append(content, "image: v2\n"...), nil
+	}
+	opt := &ModifyFileOptions{Branch: String("master"), CommitMessage: String("Bump image")}
+
+	info, _, err := client.RepositoryFiles.ModifyFile(1, "config/app.yml", transform, opt)
+	if err != nil {
+		t.Fatalf("RepositoryFiles.ModifyFile returned error: %v", err)
+	}
+	if info.FilePath != "config/app.yml" || reads != 2 || writes != 2 {
+		t.Errorf("RepositoryFiles.ModifyFile returned %+v after %d reads and %d writes", info, reads, writes)
+	}
+
+	// Unchanged content doesn't create a commit.
+	info, _, err = client.RepositoryFiles.ModifyFile(1, "config/app.yml", func(c []byte) ([]byte, error) { return c, nil }, opt)
+	if err != nil || info != nil || writes != 2 {
+		t.Errorf("RepositoryFiles.ModifyFile returned %+v, %v", info, err)
+	}
+}
+
+func TestModifyFileConflict(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/files/new.txt", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"404 File Not Found"}`)
+		case "POST":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message":"A file with this name already exists"}`)
+		}
+	})
+
+	_, _, err := client.RepositoryFiles.ModifyFile(1, "new.txt", func(c []byte) ([]byte, error) {
+		if c != nil {
+			t.Errorf("FileTransform got %q for a missing file", c)
+		}
+		return []byte("hello"), nil
+	}, &ModifyFileOptions{Branch: String("master"), MaxRetries: 2})
+
+	ce, ok := err.(*FileConflictError)
+	if !ok || ce.Attempts != 3 || !IsFileConflict(ce.Err) {
+		t.Fatalf("RepositoryFiles.ModifyFile returned %v, want a conflict after 3 attempts", err)
+	}
+	if !strings.Contains(err.Error(), "new.txt on branch master") {
+		t.Errorf("FileConflictError is %q", err)
+	}
+}
@@ -35,14 +35,15 @@ type RepositoryFilesService struct {
 //
 // GitLab API docs: https://docs.gitlab.com/ce/api/repository_files.html
 type File struct {
-	FileName string `json:"file_name"`
-	FilePath string `json:"file_path"`
-	Size     int    `json:"size"`
-	Encoding string `json:"encoding"`
-	Content  string `json:"content"`
-	Ref      string `json:"ref"`
-	BlobID   string `json:"blob_id"`
-	CommitID string `json:"commit_id"`
+	FileName     string `json:"file_name"`
+	FilePath     string `json:"file_path"`
+	Size         int    `json:"size"`
+	Encoding     string `json:"encoding"`
+	Content      string `json:"content"`
+	Ref          string `json:"ref"`
+	BlobID       string `json:"blob_id"`
+	CommitID     string `json:"commit_id"`
+	LastCommitID string `json:"last_commit_id"`
 }
 
 func (r File) String() string {
@@ -122,12 +123,13 @@ func (s *RepositoryFilesService) GetFileMetaData(pid interface{}, fileName strin
 	}
 
 	f := &File{
-		BlobID:   resp.Header.Get("X-Gitlab-Blob-Id"),
-		CommitID: resp.Header.Get("X-Gitlab-Last-Commit-Id"),
-		Encoding: resp.Header.Get("X-Gitlab-Encoding"),
-		FileName: resp.Header.Get("X-Gitlab-File-Name"),
-		FilePath: resp.Header.Get("X-Gitlab-File-Path"),
-		Ref:      resp.Header.Get("X-Gitlab-Ref"),
+		BlobID:       resp.Header.Get("X-Gitlab-Blob-Id"),
+		CommitID:     resp.Header.Get("X-Gitlab-Last-Commit-Id"),
+		LastCommitID: resp.Header.Get("X-Gitlab-Last-Commit-Id"),
+		Encoding:     resp.Header.Get("X-Gitlab-Encoding"),
+		FileName:     resp.Header.Get("X-Gitlab-File-Name"),
+		FilePath:     resp.Header.Get("X-Gitlab-File-Path"),
+		Ref:          resp.Header.Get("X-Gitlab-Ref"),
 	}
 
 	if sizeString := resp.Header.Get("X-Gitlab-Size"); sizeString != "" {
@@ -140,6 +142,84 @@ func (s *RepositoryFilesService) GetFileMetaData(pid interface{}, fileName strin
 	return f, resp, err
 }
 
+// FileBlameRange represents one item of blame information: a range of
+// consecutive lines that were last changed by the same commit.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/repository_files.html#get-file-blame-from-repository
+type FileBlameRange struct {
+	Commit *Commit  `json:"commit"`
+	Lines  []string `json:"lines"`
+
+	// StartLine is the number of the first line of the range. It is not
+	// returned by GitLab, but set by GetFileBlame.
+	StartLine int `json:"-"`
+}
+
+func (b FileBlameRange) String() string {
+	return Stringify(b)
+}
+
+// EndLine returns the number of the last line of the range.
+func (b *FileBlameRange) EndLine() int {
+	return b.StartLine + len(b.Lines) - 1
+}
+
+// GetFileBlameOptions represents the available GetFileBlame() options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/repository_files.html#get-file-blame-from-repository
+type GetFileBlameOptions struct {
+	Ref   *string         `url:"ref,omitempty" json:"ref,omitempty"`
+	Range *BlameLineRange `url:"range,omitempty" json:"range,omitempty"`
+}
+
+// BlameLineRange limits the blame to the lines from Start to End,
+// inclusive.
+type BlameLineRange struct {
+	Start int `url:"start" json:"start"`
+	End   int `url:"end" json:"end"`
+}
+
+// GetFileBlame allows you to receive blame information. Each blame range
+// contains lines and their corresponding commit information.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/repository_files.html#get-file-blame-from-repository
+func (s *RepositoryFilesService) GetFileBlame(pid interface{}, file string, opt *GetFileBlameOptions, options ...OptionFunc) ([]*FileBlameRange, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf(
+		"projects/%s/repository/files/%s/blame",
+		url.QueryEscape(project),
+		url.PathEscape(file),
+	)
+
+	req, err := s.client.NewRequest("GET", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var br []*FileBlameRange
+	resp, err := s.client.Do(req, &br)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	line := 1
+	if opt != nil && opt.Range != nil {
+		line = opt.Range.Start
+	}
+	for _, b := range br {
+		b.StartLine = line
+		line += len(b.Lines)
+	}
+
+	return br, resp, err
+}
+
 // GetRawFileOptions represents the available GetRawFile() options.
 //
 // GitLab API docs:
@@ -283,6 +363,7 @@ type DeleteFileOptions struct {
 	AuthorEmail   *string `url:"author_email,omitempty" json:"author_email,omitempty"`
 	AuthorName    *string `url:"author_name,omitempty" json:"author_name,omitempty"`
 	CommitMessage *string `url:"commit_message,omitempty" json:"commit_message,omitempty"`
+	LastCommitID  *string `url:"last_commit_id,omitempty" json:"last_commit_id,omitempty"`
 }
 
 // DeleteFile deletes an existing file in a repository
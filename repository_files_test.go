@@ -0,0 +1,44 @@
+package gitlab
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetFileMetaData(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/files/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "HEAD")
+		testURL(t, r, "/api/v4/projects/1/repository/files/src%2Fmain.go?ref=master")
+		w.Header().Set("X-Gitlab-Blob-Id", "b1")
+		w.Header().Set("X-Gitlab-Commit-Id", "c1")
+		w.Header().Set("X-Gitlab-Last-Commit-Id", "c0")
+		w.Header().Set("X-Gitlab-Encoding", "base64")
+		w.Header().Set("X-Gitlab-File-Name", "main.go")
+		w.Header().Set("X-Gitlab-File-Path", "src/main.go")
+		w.Header().Set("X-Gitlab-Ref", "master")
+		w.Header().Set("X-Gitlab-Size", "42")
+	})
+
+	file, _, err := client.RepositoryFiles.GetFileMetaData(1, "src/main.go", &GetFileMetaDataOptions{Ref: String("master")})
+	if err != nil {
+		t.Fatalf("RepositoryFiles.GetFileMetaData returned error: %v", err)
+	}
+
+	want := &File{
+		FileName:     "main.go",
+		FilePath:     "src/main.go",
+		Size:         42,
+		Encoding:     "base64",
+		Ref:          "master",
+		BlobID:       "b1",
+		CommitID:     "c0",
+		LastCommitID: "c0",
+	}
+	if !reflect.DeepEqual(want, file) {
+		t.Errorf("RepositoryFiles.GetFileMetaData returned %+v, want %+v", file, want)
+	}
+}
@@ -0,0 +1,382 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//go:build go1.16
+// +build go1.16
+
+package gitlab
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RepositoryFS represents a read-only view of a repository at a given ref.
+// It implements fs.FS, fs.ReadDirFS, fs.ReadFileFS and fs.StatFS, so it can
+// be used with fs.WalkDir, fs.Glob, template.ParseFS and the like. It is
+// only available with Go 1.16 and later.
+//
+// The tree is listed once when the RepositoryFS is created. File contents
+// are fetched when a file is first read and cached by blob SHA, so files
+// with the same content are only fetched once.
+//
+// Symbolic links are not followed: reading one returns its target.
+// Submodules are reported as irregular files without content.
+type RepositoryFS struct {
+	client  *Client
+	pid     interface{}
+	ref     string
+	options []OptionFunc
+
+	nodes    map[string]*TreeNode
+	children map[string][]*TreeNode
+
+	mu       sync.Mutex
+	contents map[string][]byte
+	sizes    map[string]int64
+}
+
+// FS returns a read-only file system of a project at the given ref. The
+// complete tree is listed recursively before FS returns.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/repositories.html#list-repository-tree
+func (s *RepositoriesService) FS(pid interface{}, ref string, options ...OptionFunc) (*RepositoryFS, error) {
+	fsys := &RepositoryFS{
+		client:   s.client,
+		pid:      pid,
+		ref:      ref,
+		options:  options,
+		nodes:    make(map[string]*TreeNode),
+		children: make(map[string][]*TreeNode),
+		contents: make(map[string][]byte),
+		sizes:    make(map[string]int64),
+	}
+
+	opt := &ListTreeOptions{
+		ListOptions: ListOptions{Page: 1, PerPage: 100},
+		Ref:         String(ref),
+		Recursive:   Bool(true),
+	}
+	for {
+		nodes, resp, err := s.ListTree(pid, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			fsys.nodes[n.Path] = n
+			dir := path.Dir(n.Path)
+			fsys.children[dir] = append(fsys.children[dir], n)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	for _, c := range fsys.children {
+		sort.Slice(c, func(i, j int) bool { return c[i].Name < c[j].Name })
+	}
+
+	return fsys, nil
+}
+
+// Ref returns the ref of the file system.
+func (fsys *RepositoryFS) Ref() string {
+	return fsys.ref
+}
+
+// node returns the tree node of name, or nil for the root directory.
+func (fsys *RepositoryFS) node(op, name string) (*TreeNode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return nil, nil
+	}
+	n, ok := fsys.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return n, nil
+}
+
+// Open implements fs.FS.
+func (fsys *RepositoryFS) Open(name string) (fs.File, error) {
+	n, err := fsys.node("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil || n.Type == "tree" {
+		return &repositoryDir{fsys: fsys, info: fsys.info(n), path: name}, nil
+	}
+	return &repositoryFile{fsys: fsys, node: n}, nil
+}
+
+// ReadDir implements fs.ReadDirFS. The entries are sorted by name.
+func (fsys *RepositoryFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := fsys.node("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if n != nil && n.Type != "tree" {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	var entries []fs.DirEntry
+	for _, c := range fsys.children[name] {
+		entries = append(entries, &repositoryDirEntry{fsys: fsys, node: c})
+	}
+	return entries, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (fsys *RepositoryFS) ReadFile(name string) ([]byte, error) {
+	n, err := fsys.node("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil || n.Type == "tree" {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+
+	content, err := fsys.content(n)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+
+	// The cached content must not be modified by the caller.
+	return append([]byte(nil), content...), nil
+}
+
+// Stat implements fs.StatFS.
+func (fsys *RepositoryFS) Stat(name string) (fs.FileInfo, error) {
+	n, err := fsys.node("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := fsys.info(n)
+	if info.Mode().IsRegular() || info.Mode()&fs.ModeSymlink != 0 {
+		if info.size, err = fsys.size(n); err != nil {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+		}
+	}
+	return info, nil
+}
+
+// content returns the content of a blob, fetching it if it is not cached.
+func (fsys *RepositoryFS) content(n *TreeNode) ([]byte, error) {
+	if n.Type != "blob" {
+		return nil, nil
+	}
+
+	fsys.mu.Lock()
+	content, ok := fsys.contents[n.ID]
+	fsys.mu.Unlock()
+	if ok {
+		return content, nil
+	}
+
+	content, _, err := fsys.client.Repositories.RawBlobContent(fsys.pid, n.ID, fsys.options...)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys.mu.Lock()
+	fsys.contents[n.ID] = content
+	fsys.sizes[n.ID] = int64(len(content))
+	fsys.mu.Unlock()
+
+	return content, nil
+}
+
+// size returns the size of a blob without fetching its content.
+func (fsys *RepositoryFS) size(n *TreeNode) (int64, error) {
+	if n.Type != "blob" {
+		return 0, nil
+	}
+
+	fsys.mu.Lock()
+	size, ok := fsys.sizes[n.ID]
+	fsys.mu.Unlock()
+	if ok {
+		return size, nil
+	}
+
+	f, _, err := fsys.client.RepositoryFiles.GetFileMetaData(fsys.pid, n.Path, &GetFileMetaDataOptions{Ref: String(fsys.ref)}, fsys.options...)
+	if err != nil {
+		return 0, err
+	}
+
+	fsys.mu.Lock()
+	fsys.sizes[n.ID] = int64(f.Size)
+	fsys.mu.Unlock()
+
+	return int64(f.Size), nil
+}
+
+func (fsys *RepositoryFS) info(n *TreeNode) *repositoryFileInfo {
+	if n == nil {
+		return &repositoryFileInfo{name: ".", mode: fs.ModeDir | 0755}
+	}
+
+	info := &repositoryFileInfo{name: n.Name, node: n}
+	switch {
+	case n.Type == "tree":
+		info.mode = fs.ModeDir | 0755
+	case n.Type == "commit":
+		info.mode = fs.ModeIrregular
+	case n.Mode == "120000":
+		info.mode = fs.ModeSymlink | 0777
+	case n.Mode == "100755":
+		info.mode = 0755
+	default:
+		info.mode = 0644
+	}
+	return info
+}
+
+// repositoryFileInfo implements fs.FileInfo. The modification time is not
+// known and always zero.
+type repositoryFileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+	node *TreeNode
+}
+
+func (i *repositoryFileInfo) Name() string       { return i.name }
+func (i *repositoryFileInfo) Size() int64        { return i.size }
+func (i *repositoryFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i *repositoryFileInfo) ModTime() time.Time { return time.Time{} }
+func (i *repositoryFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *repositoryFileInfo) Sys() interface{}   { return i.node }
+
+// repositoryDirEntry implements fs.DirEntry.
+type repositoryDirEntry struct {
+	fsys *RepositoryFS
+	node *TreeNode
+}
+
+func (e *repositoryDirEntry) Name() string      { return e.node.Name }
+func (e *repositoryDirEntry) IsDir() bool       { return e.node.Type == "tree" }
+func (e *repositoryDirEntry) Type() fs.FileMode { return e.fsys.info(e.node).Mode().Type() }
+
+func (e *repositoryDirEntry) Info() (fs.FileInfo, error) {
+	return e.fsys.Stat(e.node.Path)
+}
+
+// repositoryFile implements fs.File for blobs, symbolic links and
+// submodules. The content is fetched on the first read.
+type repositoryFile struct {
+	fsys   *RepositoryFS
+	node   *TreeNode
+	reader *bytes.Reader
+}
+
+func (f *repositoryFile) Stat() (fs.FileInfo, error) {
+	return f.fsys.Stat(f.node.Path)
+}
+
+func (f *repositoryFile) open() error {
+	if f.reader != nil {
+		return nil
+	}
+	content, err := f.fsys.content(f.node)
+	if err != nil {
+		return &fs.PathError{Op: "read", Path: f.node.Path, Err: err}
+	}
+	f.reader = bytes.NewReader(content)
+	return nil
+}
+
+func (f *repositoryFile) Read(b []byte) (int, error) {
+	if err := f.open(); err != nil {
+		return 0, err
+	}
+	return f.reader.Read(b)
+}
+
+// ReadAt implements io.ReaderAt.
+func (f *repositoryFile) ReadAt(b []byte, off int64) (int, error) {
+	if err := f.open(); err != nil {
+		return 0, err
+	}
+	return f.reader.ReadAt(b, off)
+}
+
+// Seek implements io.Seeker.
+func (f *repositoryFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.open(); err != nil {
+		return 0, err
+	}
+	return f.reader.Seek(offset, whence)
+}
+
+func (f *repositoryFile) Close() error {
+	return nil
+}
+
+// repositoryDir implements fs.ReadDirFile.
+type repositoryDir struct {
+	fsys    *RepositoryFS
+	info    *repositoryFileInfo
+	path    string
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *repositoryDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *repositoryDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: fs.ErrInvalid}
+}
+
+func (d *repositoryDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.entries == nil {
+		d.entries, _ = d.fsys.ReadDir(d.path)
+		if d.entries == nil {
+			d.entries = []fs.DirEntry{}
+		}
+	}
+
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
+
+func (d *repositoryDir) Close() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+//go:build go1.16
+// +build go1.16
+
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRepositoryFS(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	blobs := map[string]string{
+		"b1": "# Project\n",
+		"b2": "package main\n",
+		"b3": "main.go",
+	}
+
+	mux.HandleFunc("/api/v4/projects/1/repository/tree", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		q := r.URL.Query()
+		if q.Get("ref") != "v1.0" || q.Get("recursive") != "true" {
+			t.Errorf("Request query is %v", q)
+		}
+		if q.Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[
+				{"id":"t1","name":"cmd","type":"tree","path":"cmd","mode":"040000"},
+				{"id":"b1","name":"README.md","type":"blob","path":"README.md","mode":"100644"}
+			]`)
+			return
+		}
+		fmt.Fprint(w, `[
+			{"id":"b2","name":"main.go","type":"blob","path":"cmd/main.go","mode":"100755"},
+			{"id":"b1","name":"COPY.md","type":"blob","path":"cmd/COPY.md","mode":"100644"},
+			{"id":"b3","name":"link.go","type":"blob","path":"cmd/link.go","mode":"120000"}
+		]`)
+	})
+
+	fetches := make(map[string]int)
+	mux.HandleFunc("/api/v4/projects/1/repository/blobs/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		sha := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v4/projects/1/repository/blobs/"), "/raw")
+		fetches[sha]++
+		fmt.Fprint(w, blobs[sha])
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/files/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "HEAD")
+		ids := map[string]string{"README.md": "b1", "cmd/COPY.md": "b1", "cmd/main.go": "b2", "cmd/link.go": "b3"}
+		id := ids[strings.TrimPrefix(r.URL.Path, "/api/v4/projects/1/repository/files/")]
+		w.Header().Set("X-Gitlab-Size", fmt.Sprint(len(blobs[id])))
+	})
+
+	fsys, err := client.Repositories.FS(1, "v1.0")
+	if err != nil {
+		t.Fatalf("Repositories.FS returned error: %v", err)
+	}
+
+	if err := fstest.TestFS(fsys, "README.md", "cmd/main.go", "cmd/COPY.md", "cmd/link.go"); err != nil {
+		t.Fatal(err)
+	}
+	if fetches["b1"] != 1 {
+		t.Errorf("Blob b1 was fetched %d times, want 1", fetches["b1"])
+	}
+
+	var walked []string
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		walked = append(walked, p)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("fs.WalkDir returned error: %v", err)
+	}
+	if got := strings.Join(walked, " "); got != ". README.md cmd cmd/COPY.md cmd/link.go cmd/main.go" {
+		t.Errorf("fs.WalkDir walked %s", got)
+	}
+
+	info, err := fs.Stat(fsys, "cmd/main.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned error: %v", err)
+	}
+	if info.Size() != 13 || info.Mode() != 0755 {
+		t.Errorf("fs.Stat returned size %d and mode %v", info.Size(), info.Mode())
+	}
+	if info, _ := fs.Stat(fsys, "cmd/link.go"); info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("fs.Stat returned mode %v for a symbolic link", info.Mode())
+	}
+
+	if _, err := fsys.Open("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open returned %v, want fs.ErrNotExist", err)
+	}
+	if _, err := fsys.Open("../etc/passwd"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Open returned %v, want fs.ErrInvalid", err)
+	}
+}
@@ -0,0 +1,439 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-querystring/query"
+)
+
+// jobTokenHeader is the header used to authenticate requests made with a
+// job token.
+const jobTokenHeader = "JOB-TOKEN"
+
+// newRunnerRequest creates a request for the runner API. These requests are
+// authenticated with the token of a runner or a job, so the credentials of
+// the client are not sent.
+func (s *RunnersService) newRunnerRequest(method, path string, opt interface{}, options []OptionFunc) (*http.Request, error) {
+	req, err := s.client.NewRequest(method, path, opt, options)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Del("Authorization")
+	req.Header.Del("PRIVATE-TOKEN")
+	return req, nil
+}
+
+// RunnerFeatures represents the features a runner announces when it
+// requests a job.
+type RunnerFeatures struct {
+	Variables               bool `json:"variables,omitempty"`
+	Image                   bool `json:"image,omitempty"`
+	Services                bool `json:"services,omitempty"`
+	Artifacts               bool `json:"artifacts,omitempty"`
+	Cache                   bool `json:"cache,omitempty"`
+	Shared                  bool `json:"shared,omitempty"`
+	UploadMultipleArtifacts bool `json:"upload_multiple_artifacts,omitempty"`
+	UploadRawArtifacts      bool `json:"upload_raw_artifacts,omitempty"`
+	Session                 bool `json:"session,omitempty"`
+	Terminal                bool `json:"terminal,omitempty"`
+	Refspecs                bool `json:"refspecs,omitempty"`
+	Masking                 bool `json:"masking,omitempty"`
+	Proxy                   bool `json:"proxy,omitempty"`
+	RawVariables            bool `json:"raw_variables,omitempty"`
+	ArtifactsExclude        bool `json:"artifacts_exclude,omitempty"`
+	MultiBuildSteps         bool `json:"multi_build_steps,omitempty"`
+	TraceReset              bool `json:"trace_reset,omitempty"`
+	TraceChecksum           bool `json:"trace_checksum,omitempty"`
+	TraceSize               bool `json:"trace_size,omitempty"`
+	Cancelable              bool `json:"cancelable,omitempty"`
+	ReturnExitCode          bool `json:"return_exit_code,omitempty"`
+}
+
+// RunnerInfo represents the information a runner sends about itself when it
+// requests a job.
+type RunnerInfo struct {
+	Name         string          `json:"name,omitempty"`
+	Version      string          `json:"version,omitempty"`
+	Revision     string          `json:"revision,omitempty"`
+	Platform     string          `json:"platform,omitempty"`
+	Architecture string          `json:"architecture,omitempty"`
+	Executor     string          `json:"executor,omitempty"`
+	Shell        string          `json:"shell,omitempty"`
+	Features     *RunnerFeatures `json:"features,omitempty"`
+}
+
+// RunnerJob represents a job as it is handed out to a runner.
+type RunnerJob struct {
+	ID            int    `json:"id"`
+	Token         string `json:"token"`
+	AllowGitFetch bool   `json:"allow_git_fetch"`
+	JobInfo       struct {
+		Name        string `json:"name"`
+		Stage       string `json:"stage"`
+		ProjectID   int    `json:"project_id"`
+		ProjectName string `json:"project_name"`
+	} `json:"job_info"`
+	GitInfo struct {
+		RepoURL   string   `json:"repo_url"`
+		Ref       string   `json:"ref"`
+		Sha       string   `json:"sha"`
+		BeforeSha string   `json:"before_sha"`
+		RefType   string   `json:"ref_type"`
+		Refspecs  []string `json:"refspecs"`
+		Depth     int      `json:"depth"`
+	} `json:"git_info"`
+	RunnerInfo struct {
+		Timeout int `json:"timeout"`
+	} `json:"runner_info"`
+	Variables    []*RunnerJobVariable    `json:"variables"`
+	Steps        []*RunnerJobStep        `json:"steps"`
+	Image        *RunnerJobImage         `json:"image"`
+	Services     []*RunnerJobImage       `json:"services"`
+	Artifacts    []*RunnerJobArtifacts   `json:"artifacts"`
+	Cache        []*RunnerJobCache       `json:"cache"`
+	Credentials  []*RunnerJobCredentials `json:"credentials"`
+	Dependencies []*RunnerJobDependency  `json:"dependencies"`
+}
+
+// RunnerJobVariable represents a variable of a job handed out to a runner.
+type RunnerJobVariable struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Public bool   `json:"public"`
+	File   bool   `json:"file"`
+	Masked bool   `json:"masked"`
+	Raw    bool   `json:"raw"`
+}
+
+// RunnerJobStep represents a step, like the script, of a job handed out to a
+// runner.
+type RunnerJobStep struct {
+	Name         string   `json:"name"`
+	Script       []string `json:"script"`
+	Timeout      int      `json:"timeout"`
+	When         string   `json:"when"`
+	AllowFailure bool     `json:"allow_failure"`
+}
+
+// RunnerJobImage represents the image or a service of a job handed out to a
+// runner.
+type RunnerJobImage struct {
+	Name       string   `json:"name"`
+	Alias      string   `json:"alias"`
+	Command    []string `json:"command"`
+	Entrypoint []string `json:"entrypoint"`
+}
+
+// RunnerJobArtifacts represents the artifacts a runner has to upload for a
+// job.
+type RunnerJobArtifacts struct {
+	Name           string   `json:"name"`
+	Untracked      bool     `json:"untracked"`
+	Paths          []string `json:"paths"`
+	Exclude        []string `json:"exclude"`
+	When           string   `json:"when"`
+	ArtifactType   string   `json:"artifact_type"`
+	ArtifactFormat string   `json:"artifact_format"`
+	ExpireIn       string   `json:"expire_in"`
+}
+
+// RunnerJobCache represents a cache of a job handed out to a runner.
+type RunnerJobCache struct {
+	Key       string   `json:"key"`
+	Untracked bool     `json:"untracked"`
+	Policy    string   `json:"policy"`
+	Paths     []string `json:"paths"`
+	When      string   `json:"when"`
+}
+
+// RunnerJobCredentials represents credentials, like registry credentials,
+// of a job handed out to a runner.
+type RunnerJobCredentials struct {
+	Type     string `json:"type"`
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RunnerJobDependency represents a job whose artifacts a job depends on.
+// Its artifacts are downloaded with the token of the depending job.
+type RunnerJobDependency struct {
+	ID            int    `json:"id"`
+	Token         string `json:"token"`
+	Name          string `json:"name"`
+	ArtifactsFile struct {
+		Filename string `json:"filename"`
+		Size     int    `json:"size"`
+	} `json:"artifacts_file"`
+}
+
+// RequestJobOptions represents the available RequestJob() options.
+type RequestJobOptions struct {
+	Token      *string     `url:"token" json:"token"`
+	Info       *RunnerInfo `url:"-" json:"info,omitempty"`
+	LastUpdate *string     `url:"last_update,omitempty" json:"last_update,omitempty"`
+}
+
+// RequestJob requests a new job for the runner with the given token. A nil
+// job is returned if there is no job to run. The X-GitLab-Last-Update
+// header of the response should be sent as LastUpdate with the next
+// request, to allow GitLab to answer from its cache.
+func (s *RunnersService) RequestJob(opt *RequestJobOptions, options ...OptionFunc) (*RunnerJob, *Response, error) {
+	req, err := s.newRunnerRequest("POST", "jobs/request", opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	resp, err := s.client.Do(req, buf)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, resp, nil
+	}
+
+	j := new(RunnerJob)
+	if err := json.Unmarshal(buf.Bytes(), j); err != nil {
+		return nil, resp, err
+	}
+
+	return j, resp, nil
+}
+
+// RunnerJobStateValue represents a state of a job reported by a runner.
+type RunnerJobStateValue string
+
+// These constants represent all valid job states reported by a runner.
+const (
+	RunnerJobRunning RunnerJobStateValue = "running"
+	RunnerJobSuccess RunnerJobStateValue = "success"
+	RunnerJobFailed  RunnerJobStateValue = "failed"
+)
+
+// RunnerJobState is a helper routine that allocates a new RunnerJobStateValue
+// to store v and returns a pointer to it.
+func RunnerJobState(v RunnerJobStateValue) *RunnerJobStateValue {
+	p := new(RunnerJobStateValue)
+	*p = v
+	return p
+}
+
+// UpdateJobOptions represents the available UpdateJob() options.
+type UpdateJobOptions struct {
+	Token         *string              `url:"token" json:"token"`
+	State         *RunnerJobStateValue `url:"state,omitempty" json:"state,omitempty"`
+	FailureReason *string              `url:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	ExitCode      *int                 `url:"exit_code,omitempty" json:"exit_code,omitempty"`
+	Checksum      *string              `url:"checksum,omitempty" json:"checksum,omitempty"`
+}
+
+// UpdateJob updates the state of a job, using the token of the job. GitLab
+// responds with 403 Forbidden when the job is no longer running, for
+// example because it was canceled.
+func (s *RunnersService) UpdateJob(jobID int, opt *UpdateJobOptions, options ...OptionFunc) (*Response, error) {
+	u := fmt.Sprintf("jobs/%d", jobID)
+
+	req, err := s.newRunnerRequest("PUT", u, opt, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.client.Do(req, nil)
+}
+
+// AppendJobTrace appends content to the trace of a job, starting at the
+// given byte offset. The offset must match the length of the trace already
+// sent. If it does not, GitLab responds with 416 Range Not Satisfiable and
+// the Range header of the response holds the range it knows about.
+//
+// The response contains the Job-Status header with the status of the job
+// and the X-GitLab-Trace-Update-Interval header with the interval in
+// seconds GitLab expects between two updates. No request is made, and a nil
+// response is returned, if content is empty.
+func (s *RunnersService) AppendJobTrace(jobID int, token string, offset int64, content []byte, options ...OptionFunc) (*Response, error) {
+	if len(content) == 0 {
+		return nil, nil
+	}
+
+	u := fmt.Sprintf("jobs/%d/trace", jobID)
+
+	req, err := s.newRunnerRequest("PATCH", u, nil, options)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(content))
+	req.ContentLength = int64(len(content))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+int64(len(content))-1))
+	req.Header.Set(jobTokenHeader, token)
+
+	return s.client.Do(req, nil)
+}
+
+// JobTraceUpdateInterval returns the interval GitLab expects between two
+// trace updates, as sent in a response of AppendJobTrace. Zero is returned
+// if the response has no interval.
+func JobTraceUpdateInterval(resp *Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("X-GitLab-Trace-Update-Interval"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// UploadJobArtifactsOptions represents the available UploadJobArtifacts()
+// options.
+type UploadJobArtifactsOptions struct {
+	ArtifactFormat *string `url:"artifact_format,omitempty" json:"artifact_format,omitempty"`
+	ArtifactType   *string `url:"artifact_type,omitempty" json:"artifact_type,omitempty"`
+	ExpireIn       *string `url:"expire_in,omitempty" json:"expire_in,omitempty"`
+}
+
+// UploadJobArtifacts uploads an artifacts file for a job, using the token
+// of the job. The content is streamed, so it is never held in memory
+// completely.
+func (s *RunnersService) UploadJobArtifacts(jobID int, token string, filename string, content io.Reader, opt *UploadJobArtifactsOptions, options ...OptionFunc) (*Response, error) {
+	u := fmt.Sprintf("jobs/%d/artifacts", jobID)
+
+	req, err := s.newRunnerRequest("POST", u, nil, options)
+	if err != nil {
+		return nil, err
+	}
+	if opt != nil {
+		q, err := query.Values(opt)
+		if err != nil {
+			return nil, err
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+
+	go func() {
+		fw, err := w.CreateFormFile("file", filename)
+		if err == nil {
+			_, err = io.Copy(fw, content)
+		}
+		if err == nil {
+			err = w.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req.Body = pr
+	req.GetBody = nil
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set(jobTokenHeader, token)
+
+	resp, err := s.client.Do(req, nil)
+
+	// Unblock the writer if the request failed before the body was read.
+	pr.Close()
+
+	return resp, err
+}
+
+// DownloadJobArtifacts downloads the artifacts file of a job and writes it
+// to w. The token is the token of the job requesting the artifacts, which
+// is allowed to download the artifacts of the jobs it depends on.
+func (s *RunnersService) DownloadJobArtifacts(jobID int, token string, w io.Writer, options ...OptionFunc) (*Response, error) {
+	u := fmt.Sprintf("jobs/%d/artifacts", jobID)
+
+	req, err := s.newRunnerRequest("GET", u, nil, options)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(jobTokenHeader, token)
+
+	return s.client.Do(req, w)
+}
+
+// DownloadCache downloads a cache archive from the given URL and writes it
+// to w. GitLab has no API for caches: runners store them in their own
+// storage, usually through pre-signed URLs, so this is a plain GET request
+// without GitLab credentials. False is returned if the cache does not exist.
+func (s *RunnersService) DownloadCache(cacheURL string, w io.Writer, options ...OptionFunc) (bool, *Response, error) {
+	req, err := s.newCacheRequest("GET", cacheURL, options)
+	if err != nil {
+		return false, nil, err
+	}
+
+	resp, err := s.client.Do(req, w)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, resp, nil
+		}
+		return false, resp, err
+	}
+
+	return true, resp, nil
+}
+
+// UploadCache uploads a cache archive of the given size to the given URL
+// with a plain PUT request, see DownloadCache.
+func (s *RunnersService) UploadCache(cacheURL string, content io.Reader, size int64, options ...OptionFunc) (*Response, error) {
+	req, err := s.newCacheRequest("PUT", cacheURL, options)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = ioutil.NopCloser(content)
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	return s.client.Do(req, nil)
+}
+
+func (s *RunnersService) newCacheRequest(method, cacheURL string, options []OptionFunc) (*http.Request, error) {
+	req, err := http.NewRequest(method, cacheURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fn := range options {
+		if fn == nil {
+			continue
+		}
+		if err := fn(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.client.UserAgent != "" {
+		req.Header.Set("User-Agent", s.client.UserAgent)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,184 @@
+package gitlab
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testNoCredentials checks that a runner API request doesn't carry the
+// credentials of the client.
+func testNoCredentials(t *testing.T, r *http.Request) {
+	for _, h := range []string{"Private-Token", "Authorization"} {
+		if _, ok := r.Header[h]; ok {
+			t.Errorf("Request has a %s header", h)
+		}
+	}
+}
+
+func TestRequestJob(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	requests := 0
+	mux.HandleFunc("/api/v4/jobs/request", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testNoCredentials(t, r)
+		requests++
+		if requests == 1 {
+			testBody(t, r, `{"token":"runner-token","info":{"name":"custom","features":{"variables":true,"trace_checksum":true}}}`)
+			w.Header().Set("X-GitLab-Last-Update", "abc")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":1,"token":"job-token","job_info":{"name":"test"},"steps":[{"name":"script","script":["make test"]}],"dependencies":[{"id":2,"token":"job-token","name":"build"}]}`)
+	})
+
+	opt := &RequestJobOptions{
+		Token: String("runner-token"),
+		Info: &RunnerInfo{
+			Name:     "custom",
+			Features: &RunnerFeatures{Variables: true, TraceChecksum: true},
+		},
+	}
+
+	job, resp, err := client.Runners.RequestJob(opt)
+	if err != nil {
+		t.Fatalf("Runners.RequestJob returned error: %v", err)
+	}
+	if job != nil || resp.Header.Get("X-GitLab-Last-Update") != "abc" {
+		t.Errorf("Runners.RequestJob returned %+v, want no job", job)
+	}
+
+	opt.LastUpdate = String("abc")
+	job, _, err = client.Runners.RequestJob(opt)
+	if err != nil {
+		t.Fatalf("Runners.RequestJob returned error: %v", err)
+	}
+	if job.ID != 1 || job.Token != "job-token" || job.Steps[0].Script[0] != "make test" || job.Dependencies[0].Name != "build" {
+		t.Errorf("Runners.RequestJob returned %+v", job)
+	}
+}
+
+func TestRunnerJobProtocol(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testNoCredentials(t, r)
+		testBody(t, r, `{"token":"job-token","state":"success","exit_code":0}`)
+	})
+	mux.HandleFunc("/api/v4/jobs/1/trace", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		testNoCredentials(t, r)
+		if r.Header.Get("JOB-TOKEN") != "job-token" || r.Header.Get("Content-Range") != "10-14" {
+			t.Errorf("Request headers are %v", r.Header)
+		}
+		testBody(t, r, "hello")
+		w.Header().Set("X-GitLab-Trace-Update-Interval", "30")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/api/v4/jobs/1/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testNoCredentials(t, r)
+		if r.URL.Query().Get("artifact_type") != "archive" || r.Header.Get("JOB-TOKEN") != "job-token" {
+			t.Errorf("Request is %v", r.URL)
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Request has no file: %v", err)
+		}
+		content, _ := ioutil.ReadAll(f)
+		if h.Filename != "artifacts.zip" || string(content) != "zip content" {
+			t.Errorf("Request has file %s with %q", h.Filename, content)
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/api/v4/jobs/2/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testNoCredentials(t, r)
+		if r.Header.Get("JOB-TOKEN") != "job-token" {
+			t.Errorf("Request headers are %v", r.Header)
+		}
+		fmt.Fprint(w, "dependency artifacts")
+	})
+
+	_, err := client.Runners.UpdateJob(1, &UpdateJobOptions{
+		Token:    String("job-token"),
+		State:    RunnerJobState(RunnerJobSuccess),
+		ExitCode: Int(0),
+	})
+	if err != nil {
+		t.Fatalf("Runners.UpdateJob returned error: %v", err)
+	}
+
+	resp, err := client.Runners.AppendJobTrace(1, "job-token", 10, []byte("hello"))
+	if err != nil {
+		t.Fatalf("Runners.AppendJobTrace returned error: %v", err)
+	}
+	if d := JobTraceUpdateInterval(resp); d != 30*time.Second {
+		t.Errorf("JobTraceUpdateInterval returned %v, want 30s", d)
+	}
+
+	// Nothing is sent for an empty update, as its Content-Range is invalid.
+	resp, err = client.Runners.AppendJobTrace(1, "job-token", 15, nil)
+	if resp != nil || err != nil {
+		t.Errorf("Runners.AppendJobTrace returned %v, %v for empty content", resp, err)
+	}
+
+	opt := &UploadJobArtifactsOptions{ArtifactType: String("archive"), ArtifactFormat: String("zip")}
+	_, err = client.Runners.UploadJobArtifacts(1, "job-token", "artifacts.zip", strings.NewReader("zip content"), opt)
+	if err != nil {
+		t.Fatalf("Runners.UploadJobArtifacts returned error: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if _, err := client.Runners.DownloadJobArtifacts(2, "job-token", buf); err != nil {
+		t.Fatalf("Runners.DownloadJobArtifacts returned error: %v", err)
+	}
+	if buf.String() != "dependency artifacts" {
+		t.Errorf("Runners.DownloadJobArtifacts returned %q", buf.String())
+	}
+}
+
+func TestRunnerCache(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	var stored []byte
+	mux.HandleFunc("/cache/key.zip", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			stored, _ = ioutil.ReadAll(r.Body)
+		case "GET":
+			if stored == nil {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(stored)
+		}
+	})
+
+	cacheURL := server.URL + "/cache/key.zip"
+
+	found, _, err := client.Runners.DownloadCache(cacheURL, new(bytes.Buffer))
+	if err != nil || found {
+		t.Fatalf("Runners.DownloadCache returned %v, %v, want false", found, err)
+	}
+
+	if _, err := client.Runners.UploadCache(cacheURL, strings.NewReader("cache"), 5); err != nil {
+		t.Fatalf("Runners.UploadCache returned error: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	found, _, err = client.Runners.DownloadCache(cacheURL, buf)
+	if err != nil || !found || buf.String() != "cache" {
+		t.Errorf("Runners.DownloadCache returned %v, %q, %v", found, buf.String(), err)
+	}
+}
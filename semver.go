@@ -0,0 +1,437 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// semverPattern matches semantic versions as defined by https://semver.org.
+const semverPattern = `(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?`
+
+var (
+	// semverRegexp matches semantic versions with an optional "v" prefix.
+	semverRegexp = regexp.MustCompile(`^[vV]?` + semverPattern + `$`)
+
+	// bareSemverRegexp matches semantic versions without a prefix.
+	bareSemverRegexp = regexp.MustCompile(`^` + semverPattern + `$`)
+)
+
+// Semver represents a semantic version.
+type Semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+// ParseSemver parses a semantic version like "1.2.3", "v1.2.3-rc.1" or
+// "1.2.3+build.5".
+func ParseSemver(s string) (*Semver, error) {
+	return parseSemver(semverRegexp, s)
+}
+
+func parseSemver(re *regexp.Regexp, s string) (*Semver, error) {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid semantic version %q", s)
+	}
+
+	v := &Semver{Prerelease: m[4], Build: m[5]}
+	for i, p := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid semantic version %q: %v", s, err)
+		}
+		*p = n
+	}
+
+	return v, nil
+}
+
+// String returns the version without a prefix.
+func (v Semver) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v has a lower, the same or a higher
+// precedence than o. Build metadata is ignored.
+func (v *Semver) Compare(o *Semver) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	// A pre-release has a lower precedence than the release.
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.Prerelease, "."), strings.Split(o.Prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePrereleaseIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// comparePrereleaseIdentifier compares identifiers numerically if both are
+// numeric, and lexically otherwise. Numeric identifiers come first.
+func comparePrereleaseIdentifier(a, b string) int {
+	an, aerr := strconv.Atoi(a)
+	bn, berr := strconv.Atoi(b)
+	switch {
+	case aerr == nil && berr == nil:
+		return sign(an - bn)
+	case aerr == nil:
+		return -1
+	case berr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// SemverBumpValue represents the part of a semantic version to increment.
+type SemverBumpValue string
+
+// List of available semantic version bumps.
+const (
+	SemverMajor SemverBumpValue = "major"
+	SemverMinor SemverBumpValue = "minor"
+	SemverPatch SemverBumpValue = "patch"
+)
+
+// Bump returns the next version for the given bump. Pre-release and build
+// metadata are dropped. A pre-release is released by a bump that doesn't
+// go past it, so "1.2.0-rc.1" bumped by minor or patch becomes "1.2.0".
+func (v *Semver) Bump(bump SemverBumpValue) (*Semver, error) {
+	n := &Semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	pre := v.Prerelease != ""
+
+	switch bump {
+	case SemverMajor:
+		if !pre || v.Minor != 0 || v.Patch != 0 {
+			n.Major, n.Minor, n.Patch = v.Major+1, 0, 0
+		}
+	case SemverMinor:
+		if !pre || v.Patch != 0 {
+			n.Minor, n.Patch = v.Minor+1, 0
+		}
+	case SemverPatch:
+		if !pre {
+			n.Patch = v.Patch + 1
+		}
+	default:
+		return nil, fmt.Errorf("unknown version bump %q", bump)
+	}
+
+	return n, nil
+}
+
+// ConventionalBump returns the bump implied by the conventional commit
+// titles of the commits: major for breaking changes, minor for features
+// and patch for everything else. It returns "" if there are no commits.
+func ConventionalBump(commits []*Commit) SemverBumpValue {
+	var bump SemverBumpValue
+	for _, c := range commits {
+		e := newChangelogEntry(c.Title, c.Message)
+		switch {
+		case e.Breaking:
+			return SemverMajor
+		case e.Type == "feat":
+			bump = SemverMinor
+		case bump == "":
+			bump = SemverPatch
+		}
+	}
+	return bump
+}
+
+// SemverTag represents a tag named after a semantic version.
+type SemverTag struct {
+	Tag     *Tag
+	Version *Semver
+}
+
+// ListSemverTags lists the tags of a project that consist of the prefix
+// followed by a semantic version, with the highest version first. Other
+// tags are ignored. Only an empty prefix allows an optional "v", so it
+// matches both "1.2.3" and "v1.2.3", while the prefix "v" doesn't match
+// "vv1.2.3".
+func (s *TagsService) ListSemverTags(pid interface{}, prefix string, options ...OptionFunc) ([]*SemverTag, error) {
+	re := semverRegexp
+	if prefix != "" {
+		re = bareSemverRegexp
+	}
+
+	var tags []*SemverTag
+
+	opt := &ListTagsOptions{ListOptions: ListOptions{Page: 1, PerPage: 100}}
+	for {
+		ts, resp, err := s.ListTags(pid, opt, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range ts {
+			if !strings.HasPrefix(t.Name, prefix) {
+				continue
+			}
+			if v, err := parseSemver(re, strings.TrimPrefix(t.Name, prefix)); err == nil {
+				tags = append(tags, &SemverTag{Tag: t, Version: v})
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].Version.Compare(tags[j].Version) > 0 })
+
+	return tags, nil
+}
+
+// LatestSemverTags returns the tag with the highest version of every major
+// version line. Pre-releases are only considered if prerelease is true.
+func LatestSemverTags(tags []*SemverTag, prerelease bool) map[int]*SemverTag {
+	latest := make(map[int]*SemverTag)
+	for _, t := range tags {
+		if t.Version.Prerelease != "" && !prerelease {
+			continue
+		}
+		if l, ok := latest[t.Version.Major]; !ok || t.Version.Compare(l.Version) > 0 {
+			latest[t.Version.Major] = t
+		}
+	}
+	return latest
+}
+
+// SemverTagRaceError is returned by CreateSemverTag if other version tags
+// were created while the next version was computed or tagged.
+type SemverTagRaceError struct {
+	// Tag is the name of the tag CreateSemverTag wanted to create, and
+	// Created reports whether it was created anyway.
+	Tag     string
+	Created bool
+
+	// Concurrent are the tags created by others in the same version line.
+	Concurrent []*SemverTag
+}
+
+func (e *SemverTagRaceError) Error() string {
+	if !e.Created {
+		return fmt.Sprintf("tag %s was created concurrently", e.Tag)
+	}
+	var names []string
+	for _, t := range e.Concurrent {
+		names = append(names, t.Tag.Name)
+	}
+	return fmt.Sprintf("tag %s was created, but tags %s were created concurrently", e.Tag, strings.Join(names, ", "))
+}
+
+// CreateSemverTagOptions represents the available CreateSemverTag() options.
+type CreateSemverTagOptions struct {
+	// Ref is the branch or commit to tag.
+	Ref string
+
+	// Prefix is put before the version in the tag name. Only tags with
+	// this prefix are considered. Defaults to "v".
+	Prefix *string
+
+	// Major selects the major version line to release from, for patches of
+	// older versions. Defaults to the line with the highest version.
+	Major *int
+
+	// Bump is the part of the version to increment. If empty, it is
+	// derived from the conventional commit titles since the last version
+	// with ConventionalBump.
+	Bump SemverBumpValue
+
+	// Prerelease makes the new version a pre-release with this identifier,
+	// numbered after the existing pre-releases of the same version, like
+	// "rc.2".
+	Prerelease string
+
+	// Message is the message of the annotated tag. Defaults to
+	// "Release <tag>".
+	Message *string
+
+	// ReleaseDescription creates a release for the tag, in the same request
+	// as the tag.
+	ReleaseDescription *string
+}
+
+// CreateSemverTag computes the next semantic version of a project and
+// creates an annotated tag for it, with a release if a description is
+// given. Without an earlier version, the bump is applied to 0.0.0.
+//
+// If the tag was created concurrently, a *SemverTagRaceError is returned.
+// The tags are listed again after creating the tag; if other tags were
+// created in the same version line in the meantime, the created tag is
+// returned together with a *SemverTagRaceError.
+func (s *TagsService) CreateSemverTag(pid interface{}, opt *CreateSemverTagOptions, options ...OptionFunc) (*SemverTag, error) {
+	if opt == nil || opt.Ref == "" {
+		return nil, fmt.Errorf("a ref is required")
+	}
+	prefix := "v"
+	if opt.Prefix != nil {
+		prefix = *opt.Prefix
+	}
+
+	tags, err := s.ListSemverTags(pid, prefix, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The base is the latest release in the line, pre-releases are only
+	// used for numbering new pre-releases.
+	base := &Semver{}
+	var baseTag *SemverTag
+	if opt.Major != nil {
+		base.Major = *opt.Major
+		baseTag = LatestSemverTags(tags, false)[*opt.Major]
+	} else {
+		for _, t := range tags {
+			if t.Version.Prerelease == "" {
+				baseTag = t
+				break
+			}
+		}
+	}
+	if baseTag != nil {
+		base = baseTag.Version
+	}
+
+	bump := opt.Bump
+	if bump == "" {
+		bump = SemverMinor
+		if baseTag != nil {
+			cmp, _, err := s.client.Repositories.Compare(pid, &CompareOptions{
+				From: String(baseTag.Tag.Name),
+				To:   String(opt.Ref),
+			}, options...)
+			if err != nil {
+				return nil, err
+			}
+			if bump = ConventionalBump(cmp.Commits); bump == "" {
+				return nil, fmt.Errorf("no commits since %s", baseTag.Tag.Name)
+			}
+		}
+	}
+
+	next, err := base.Bump(bump)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Major != nil && next.Major != *opt.Major {
+		return nil, fmt.Errorf("a %s bump leaves major version line %d", bump, *opt.Major)
+	}
+	if opt.Prerelease != "" {
+		next.Prerelease = fmt.Sprintf("%s.%d", opt.Prerelease, nextPrereleaseNumber(tags, next, opt.Prerelease))
+	}
+
+	name := prefix + next.String()
+	message := "Release " + name
+	if opt.Message != nil {
+		message = *opt.Message
+	}
+
+	t, _, err := s.CreateTag(pid, &CreateTagOptions{
+		TagName:            String(name),
+		Ref:                String(opt.Ref),
+		Message:            String(message),
+		ReleaseDescription: opt.ReleaseDescription,
+	}, options...)
+	if err != nil {
+		if e, ok := err.(*ErrorResponse); ok && strings.Contains(e.Message, "already exists") {
+			return nil, &SemverTagRaceError{Tag: name}
+		}
+		return nil, err
+	}
+	created := &SemverTag{Tag: t, Version: next}
+
+	after, err := s.ListSemverTags(pid, prefix, options...)
+	if err != nil {
+		return created, err
+	}
+	known := map[string]bool{name: true}
+	for _, t := range tags {
+		known[t.Tag.Name] = true
+	}
+	var concurrent []*SemverTag
+	for _, t := range after {
+		if !known[t.Tag.Name] && t.Version.Major == next.Major {
+			concurrent = append(concurrent, t)
+		}
+	}
+	if len(concurrent) > 0 {
+		return created, &SemverTagRaceError{Tag: name, Created: true, Concurrent: concurrent}
+	}
+
+	return created, nil
+}
+
+// nextPrereleaseNumber returns the number after the highest existing
+// "<id>.<n>" pre-release of the version.
+func nextPrereleaseNumber(tags []*SemverTag, v *Semver, id string) int {
+	n := 0
+	for _, t := range tags {
+		tv := t.Version
+		if tv.Major != v.Major || tv.Minor != v.Minor || tv.Patch != v.Patch {
+			continue
+		}
+		if !strings.HasPrefix(tv.Prerelease, id+".") {
+			continue
+		}
+		if i, err := strconv.Atoi(strings.TrimPrefix(tv.Prerelease, id+".")); err == nil && i > n {
+			n = i
+		}
+	}
+	return n + 1
+}
@@ -0,0 +1,213 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Semver
+	}{
+		{"1.2.3", Semver{Major: 1, Minor: 2, Patch: 3}},
+		{"v0.10.0-rc.1", Semver{Minor: 10, Prerelease: "rc.1"}},
+		{"1.0.0-alpha+build.5", Semver{Major: 1, Prerelease: "alpha", Build: "build.5"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSemver(tt.in)
+		if err != nil {
+			t.Errorf("ParseSemver(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("ParseSemver(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"1.2", "01.2.3", "1.2.3-", "1.2.3-01", "release-1.2.3"} {
+		if _, err := ParseSemver(in); err == nil {
+			t.Errorf("ParseSemver(%q) returned no error", in)
+		}
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	// Ordered by precedence, from semver.org.
+	in := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.10.0", "2.0.0"}
+
+	var vs []*Semver
+	for i := len(in) - 1; i >= 0; i-- {
+		v, err := ParseSemver(in[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs = append(vs, v)
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].Compare(vs[j]) < 0 })
+
+	for i, v := range vs {
+		if v.String() != in[i] {
+			t.Errorf("position %d: got %s, want %s", i, v, in[i])
+		}
+	}
+}
+
+func TestSemverBump(t *testing.T) {
+	tests := []struct {
+		in   string
+		bump SemverBumpValue
+		want string
+	}{
+		{"1.2.3", SemverPatch, "1.2.4"},
+		{"1.2.3", SemverMinor, "1.3.0"},
+		{"1.2.3+build", SemverMajor, "2.0.0"},
+		{"1.3.0-rc.1", SemverPatch, "1.3.0"},
+		{"1.3.0-rc.1", SemverMinor, "1.3.0"},
+		{"1.3.0-rc.1", SemverMajor, "2.0.0"},
+		{"2.0.0-rc.1", SemverMajor, "2.0.0"},
+	}
+	for _, tt := range tests {
+		v, _ := ParseSemver(tt.in)
+		got, err := v.Bump(tt.bump)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("%s bumped by %s = %v, %v, want %s", tt.in, tt.bump, got, err, tt.want)
+		}
+	}
+}
+
+func TestConventionalBump(t *testing.T) {
+	tests := []struct {
+		titles []string
+		want   SemverBumpValue
+	}{
+		{nil, ""},
+		{[]string{"Update README", "fix: handle nil"}, SemverPatch},
+		{[]string{"fix: handle nil", "feat(api): add tags"}, SemverMinor},
+		{[]string{"feat!: drop v3 API", "fix: typo"}, SemverMajor},
+	}
+	for _, tt := range tests {
+		var commits []*Commit
+		for _, title := range tt.titles {
+			commits = append(commits, &Commit{Title: title})
+		}
+		if got := ConventionalBump(commits); got != tt.want {
+			t.Errorf("ConventionalBump(%v) = %q, want %q", tt.titles, got, tt.want)
+		}
+	}
+}
+
+func TestListSemverTags(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/tags", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"name": "vv3.0.0"}, {"name": "v1.0.0"}, {"name": "2.0.0"}, {"name": "release-1.5.0"}]`)
+	})
+
+	for prefix, want := range map[string][]string{
+		"v":        {"v1.0.0"},
+		"":         {"2.0.0", "v1.0.0"},
+		"release-": {"release-1.5.0"},
+	} {
+		tags, err := client.Tags.ListSemverTags(1, prefix)
+		if err != nil {
+			t.Fatalf("Tags.ListSemverTags returned error: %v", err)
+		}
+		var names []string
+		for _, tag := range tags {
+			names = append(names, tag.Tag.Name)
+		}
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("Tags.ListSemverTags(%q) returned %v, want %v", prefix, names, want)
+		}
+	}
+}
+
+func TestCreateSemverTag(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	listed := 0
+	mux.HandleFunc("/api/v4/projects/1/repository/tags", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			listed++
+			if listed == 1 {
+				fmt.Fprint(w, `[{"name": "v1.2.0"}, {"name": "v1.3.0-rc.1"}, {"name": "v2.0.0"}, {"name": "nightly"}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"name": "v1.2.0"}, {"name": "v1.3.0-rc.1"}, {"name": "v1.3.0-rc.2"}, {"name": "v2.0.0"}, {"name": "v2.0.1"}]`)
+		case "POST":
+			testBody(t, r, `{"tag_name":"v1.3.0-rc.2","ref":"1-x-stable","message":"Release v1.3.0-rc.2","release_description":"Second release candidate"}`)
+			fmt.Fprint(w, `{"name": "v1.3.0-rc.2"}`)
+		}
+	})
+	mux.HandleFunc("/api/v4/projects/1/repository/compare", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("from") != "v1.2.0" || q.Get("to") != "1-x-stable" {
+			t.Errorf("Request query is %v", q)
+		}
+		fmt.Fprint(w, `{"commits": [{"title": "fix: typo"}, {"title": "feat: add semver tags"}]}`)
+	})
+
+	tag, err := client.Tags.CreateSemverTag(1, &CreateSemverTagOptions{
+		Ref:                "1-x-stable",
+		Major:              Int(1),
+		Prerelease:         "rc",
+		ReleaseDescription: String("Second release candidate"),
+	})
+	if err != nil {
+		t.Fatalf("Tags.CreateSemverTag returned error: %v", err)
+	}
+	if tag.Tag.Name != "v1.3.0-rc.2" || tag.Version.String() != "1.3.0-rc.2" {
+		t.Errorf("Tags.CreateSemverTag returned %+v", tag)
+	}
+}
+
+func TestCreateSemverTagRace(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	listed := 0
+	mux.HandleFunc("/api/v4/projects/1/repository/tags", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			listed++
+			if listed == 1 {
+				fmt.Fprint(w, `[{"name": "v2.0.0"}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"name": "v2.0.0"}, {"name": "v2.0.1"}, {"name": "v2.1.0"}]`)
+		case "POST":
+			fmt.Fprint(w, `{"name": "v2.0.1"}`)
+		}
+	})
+
+	tag, err := client.Tags.CreateSemverTag(1, &CreateSemverTagOptions{Ref: "master", Bump: SemverPatch})
+	e, ok := err.(*SemverTagRaceError)
+	if !ok || !e.Created || len(e.Concurrent) != 1 || e.Concurrent[0].Tag.Name != "v2.1.0" {
+		t.Fatalf("Tags.CreateSemverTag returned error %v, want a race with v2.1.0", err)
+	}
+	if tag == nil || tag.Tag.Name != "v2.0.1" {
+		t.Errorf("Tags.CreateSemverTag returned %+v", tag)
+	}
+
+	mux.HandleFunc("/api/v4/projects/2/repository/tags", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `[]`)
+		case "POST":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message": "Tag v0.0.1 already exists"}`)
+		}
+	})
+
+	_, err = client.Tags.CreateSemverTag(2, &CreateSemverTagOptions{Ref: "master", Bump: SemverPatch})
+	if e, ok := err.(*SemverTagRaceError); !ok || e.Created || e.Tag != "v0.0.1" {
+		t.Errorf("Tags.CreateSemverTag returned error %v, want a race for v0.0.1", err)
+	}
+}
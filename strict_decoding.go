@@ -0,0 +1,193 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnknownFieldsFunc is called with the JSON fields of a response that were
+// ignored while decoding it, because they are not part of the Go type the
+// response was decoded into. Fields are given as dotted paths, with "[]"
+// denoting the elements of an array, e.g. "[].author.pronouns".
+type UnknownFieldsFunc func(req *http.Request, fields []string)
+
+// SetStrictDecoding enables or disables strict decoding of API responses.
+// With strict decoding enabled, JSON fields that are not part of the Go
+// type a response is decoded into are recorded on Response.UnknownFields
+// and passed to fn, if fn is not nil. API calls never fail because of
+// unknown fields.
+func (c *Client) SetStrictDecoding(strict bool, fn UnknownFieldsFunc) {
+	c.strictDecoding = strict
+	c.unknownFieldsFunc = fn
+}
+
+// ParseWebhookStrict parses the event payload like ParseWebhook, and also
+// returns the fields of the payload that are not part of the returned
+// event type.
+func ParseWebhookStrict(eventType EventType, payload []byte) (event interface{}, unknown []string, err error) {
+	event, err = ParseWebhook(eventType, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unknown, err = unknownFields(payload, event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return event, unknown, nil
+}
+
+// decodeStrict decodes the body of the response into v, and records the
+// fields of the body that are not part of v.
+func (c *Client) decodeStrict(resp *Response, v interface{}) error {
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return err
+	}
+
+	resp.UnknownFields, err = unknownFields(data, v)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.UnknownFields) > 0 && c.unknownFieldsFunc != nil {
+		c.unknownFieldsFunc(resp.Request, resp.UnknownFields)
+	}
+
+	return nil
+}
+
+// unknownFields returns the fields of the JSON data that are not part of
+// the type of v.
+func unknownFields(data []byte, v interface{}) ([]string, error) {
+	var raw interface{}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	collectUnknownFields("", raw, reflect.TypeOf(v), seen)
+
+	var fields []string
+	for f := range seen {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	return fields, nil
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func collectUnknownFields(path string, raw interface{}, t reflect.Type, unknown map[string]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Types that decode themselves can't be checked.
+	if t.Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	switch raw := raw.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+			for k, v := range raw {
+				ft, ok := fields[strings.ToLower(k)]
+				if !ok {
+					unknown[joinFieldPath(path, k)] = true
+					continue
+				}
+				collectUnknownFields(joinFieldPath(path, k), v, ft, unknown)
+			}
+		case reflect.Map:
+			for k, v := range raw {
+				collectUnknownFields(joinFieldPath(path, k), v, t.Elem(), unknown)
+			}
+		}
+
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for _, v := range raw {
+				collectUnknownFields(path+"[]", v, t.Elem(), unknown)
+			}
+		}
+	}
+}
+
+// jsonFields returns the types of the JSON fields of a struct type, keyed
+// by their lower cased name, as encoding/json matches names case
+// insensitively.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+
+	return fields
+}
+
+func joinFieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
@@ -0,0 +1,56 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/2", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":2,"status":"success","source":"push","user":{"name":"Jane","bot":false},"detailed_status":{"icon":"status_success"}}`)
+	})
+
+	var reported []string
+	client.SetStrictDecoding(true, func(req *http.Request, fields []string) {
+		reported = fields
+	})
+
+	pipeline, resp, err := client.Pipelines.GetPipeline(1, 2)
+	if err != nil {
+		t.Fatalf("Pipelines.GetPipeline returned error: %v", err)
+	}
+	if pipeline.ID != 2 || pipeline.User.Name != "Jane" {
+		t.Errorf("Pipelines.GetPipeline returned %+v", pipeline)
+	}
+
+	want := []string{"detailed_status", "source", "user.bot"}
+	if !reflect.DeepEqual(want, resp.UnknownFields) {
+		t.Errorf("Response.UnknownFields is %v, want %v", resp.UnknownFields, want)
+	}
+	if !reflect.DeepEqual(want, reported) {
+		t.Errorf("UnknownFieldsFunc was called with %v, want %v", reported, want)
+	}
+}
+
+func TestParseWebhookStrict(t *testing.T) {
+	raw := `{"object_kind":"build","build_id":1,"build_name":"test","runner":{"id":2},"commit":{"sha":"abc","author_url":"x"}}`
+
+	event, unknown, err := ParseWebhookStrict(EventTypeBuild, []byte(raw))
+	if err != nil {
+		t.Fatalf("ParseWebhookStrict returned error: %v", err)
+	}
+	if _, ok := event.(*BuildEvent); !ok {
+		t.Errorf("ParseWebhookStrict returned %T, want *BuildEvent", event)
+	}
+
+	want := []string{"commit.author_url", "runner"}
+	if !reflect.DeepEqual(want, unknown) {
+		t.Errorf("ParseWebhookStrict returned unknown fields %v, want %v", unknown, want)
+	}
+}
@@ -39,14 +39,6 @@ type Tag struct {
 	Message string   `json:"message"`
 }
 
-// Release represents a GitLab version release.
-//
-// GitLab API docs: https://docs.gitlab.com/ce/api/tags.html
-type Release struct {
-	TagName     string `json:"tag_name"`
-	Description string `json:"description"`
-}
-
 func (t Tag) String() string {
 	return Stringify(t)
 }
@@ -173,11 +165,12 @@ func (s *TagsService) DeleteTag(pid interface{}, tag string, options ...OptionFu
 // GitLab API docs:
 // https://docs.gitlab.com/ce/api/tags.html#create-a-new-release
 type CreateReleaseOptions struct {
-	Description *string `url:"description:omitempty" json:"description,omitempty"`
+	Description *string `url:"description,omitempty" json:"description,omitempty"`
 }
 
 // CreateRelease Add release notes to the existing git tag.
 // If there already exists a release for the given tag, status code 409 is returned.
+// See ReleasesService for releases with names, milestones and asset links.
 //
 // GitLab API docs:
 // https://docs.gitlab.com/ce/api/tags.html#create-a-new-release
@@ -207,7 +200,7 @@ func (s *TagsService) CreateRelease(pid interface{}, tag string, opt *CreateRele
 // GitLab API docs:
 // https://docs.gitlab.com/ce/api/tags.html#update-a-release
 type UpdateReleaseOptions struct {
-	Description *string `url:"description:omitempty" json:"description,omitempty"`
+	Description *string `url:"description,omitempty" json:"description,omitempty"`
 }
 
 // UpdateRelease Updates the release notes of a given release.
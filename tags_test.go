@@ -72,3 +72,23 @@ func TestUpdateRelease(t *testing.T) {
 		t.Errorf("Tags.UpdateRelease returned %+v, want %+v", release, want)
 	}
 }
+
+func TestReleaseDescriptionBody(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/repository/tags/1.0.0/release", func(w http.ResponseWriter, r *http.Request) {
+		testBody(t, r, `{"description":"Notes"}`)
+		fmt.Fprint(w, `{"tag_name": "1.0.0", "description": "Notes"}`)
+	})
+
+	createOpt := &CreateReleaseOptions{Description: String("Notes")}
+	if _, _, err := client.Tags.CreateRelease(1, "1.0.0", createOpt); err != nil {
+		t.Errorf("Tags.CreateRelease returned error: %v", err)
+	}
+
+	updateOpt := &UpdateReleaseOptions{Description: String("Notes")}
+	if _, _, err := client.Tags.UpdateRelease(1, "1.0.0", updateOpt); err != nil {
+		t.Errorf("Tags.UpdateRelease returned error: %v", err)
+	}
+}
@@ -1,5 +1,10 @@
 package gitlab
 
+import (
+	"fmt"
+	"net/url"
+)
+
 // ValidateService handles communication with the validation related methods of
 // the GitLab API.
 //
@@ -12,8 +17,45 @@ type ValidateService struct {
 //
 // GitLab API docs: https://docs.gitlab.com/ce/api/lint.html
 type LintResult struct {
-	Status string   `json:"status"`
-	Errors []string `json:"errors"`
+	Status     string         `json:"status"`
+	Errors     []string       `json:"errors"`
+	Warnings   []string       `json:"warnings"`
+	MergedYaml string         `json:"merged_yaml"`
+	Includes   []*LintInclude `json:"includes"`
+}
+
+// ProjectLintResult represents the linting results of a project.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/lint.html#validate-a-projects-ci-configuration
+type ProjectLintResult struct {
+	Valid      bool           `json:"valid"`
+	Errors     []string       `json:"errors"`
+	Warnings   []string       `json:"warnings"`
+	MergedYaml string         `json:"merged_yaml"`
+	Includes   []*LintInclude `json:"includes"`
+}
+
+// LintInclude represents a file included in a linted CI configuration.
+type LintInclude struct {
+	Type           string                 `json:"type"`
+	Location       string                 `json:"location"`
+	Blob           string                 `json:"blob"`
+	Raw            string                 `json:"raw"`
+	Extra          map[string]interface{} `json:"extra"`
+	ContextProject string                 `json:"context_project"`
+	ContextSHA     string                 `json:"context_sha"`
+}
+
+// Config parses the merged YAML of the linting results. The merged YAML is
+// only returned when it was requested.
+func (l *LintResult) Config() (*CIConfig, error) {
+	return ParseCIConfig([]byte(l.MergedYaml))
+}
+
+// Config parses the merged YAML of the linting results.
+func (l *ProjectLintResult) Config() (*CIConfig, error) {
+	return ParseCIConfig([]byte(l.MergedYaml))
 }
 
 // Lint validates .gitlab-ci.yml content.
@@ -38,3 +80,104 @@ func (s *ValidateService) Lint(content string, options ...OptionFunc) (*LintResu
 
 	return l, resp, nil
 }
+
+// LintOptions represents the available LintWithOptions() options.
+//
+// GitLab API docs: https://docs.gitlab.com/ce/api/lint.html
+type LintOptions struct {
+	Content           *string `url:"content,omitempty" json:"content,omitempty"`
+	IncludeMergedYAML *bool   `url:"include_merged_yaml,omitempty" json:"include_merged_yaml,omitempty"`
+}
+
+// LintWithOptions validates .gitlab-ci.yml content, optionally returning
+// the merged YAML.
+//
+// GitLab API docs: https://docs.gitlab.com/ce/api/lint.html
+func (s *ValidateService) LintWithOptions(opt *LintOptions, options ...OptionFunc) (*LintResult, *Response, error) {
+	req, err := s.client.NewRequest("POST", "ci/lint", opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := new(LintResult)
+	resp, err := s.client.Do(req, l)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return l, resp, nil
+}
+
+// ProjectLintOptions represents the available ProjectLint() options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/lint.html#validate-a-projects-ci-configuration
+type ProjectLintOptions struct {
+	DryRun *bool   `url:"dry_run,omitempty" json:"dry_run,omitempty"`
+	Ref    *string `url:"ref,omitempty" json:"ref,omitempty"`
+	SHA    *string `url:"sha,omitempty" json:"sha,omitempty"`
+}
+
+// ProjectLint validates the existing .gitlab-ci.yml of a project in the
+// context of the project.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/lint.html#validate-a-projects-ci-configuration
+func (s *ValidateService) ProjectLint(pid interface{}, opt *ProjectLintOptions, options ...OptionFunc) (*ProjectLintResult, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/ci/lint", url.QueryEscape(project))
+
+	req, err := s.client.NewRequest("GET", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := new(ProjectLintResult)
+	resp, err := s.client.Do(req, l)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return l, resp, nil
+}
+
+// ProjectNamespaceLintOptions represents the available ProjectNamespaceLint()
+// options.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/lint.html#validate-a-ci-yaml-configuration-with-a-namespace
+type ProjectNamespaceLintOptions struct {
+	Content *string `url:"content,omitempty" json:"content,omitempty"`
+	DryRun  *bool   `url:"dry_run,omitempty" json:"dry_run,omitempty"`
+	Ref     *string `url:"ref,omitempty" json:"ref,omitempty"`
+}
+
+// ProjectNamespaceLint validates .gitlab-ci.yml content in the context of
+// a project, so local includes and project variables are resolved. With
+// DryRun a pipeline creation is simulated for the given ref.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ce/api/lint.html#validate-a-ci-yaml-configuration-with-a-namespace
+func (s *ValidateService) ProjectNamespaceLint(pid interface{}, opt *ProjectNamespaceLintOptions, options ...OptionFunc) (*ProjectLintResult, *Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/ci/lint", url.QueryEscape(project))
+
+	req, err := s.client.NewRequest("POST", u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := new(ProjectLintResult)
+	resp, err := s.client.Do(req, l)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return l, resp, nil
+}
@@ -69,3 +69,54 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestProjectNamespaceLint(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/ci/lint", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testBody(t, r, `{"content":"include: ci/build.yml","dry_run":true,"ref":"feature"}`)
+		fmt.Fprint(w, `{
+			"valid": true,
+			"errors": [],
+			"warnings": ["jobs:build may allow multiple pipelines to run"],
+			"merged_yaml": "build:\n  script: make\n",
+			"includes": [{"type":"local","location":"ci/build.yml","context_project":"group/project","context_sha":"abc"}]
+		}`)
+	})
+
+	opt := &ProjectNamespaceLintOptions{
+		Content: String("include: ci/build.yml"),
+		DryRun:  Bool(true),
+		Ref:     String("feature"),
+	}
+	got, _, err := client.Validate.ProjectNamespaceLint(1, opt)
+	if err != nil {
+		t.Fatalf("Validate.ProjectNamespaceLint returned error: %v", err)
+	}
+
+	want := &ProjectLintResult{
+		Valid:      true,
+		Errors:     []string{},
+		Warnings:   []string{"jobs:build may allow multiple pipelines to run"},
+		MergedYaml: "build:\n  script: make\n",
+		Includes: []*LintInclude{{
+			Type:           "local",
+			Location:       "ci/build.yml",
+			ContextProject: "group/project",
+			ContextSHA:     "abc",
+		}},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Validate.ProjectNamespaceLint returned \ngot:\n%v\nwant:\n%v", Stringify(got), Stringify(want))
+	}
+
+	c, err := got.Config()
+	if err != nil {
+		t.Fatalf("ProjectLintResult.Config returned error: %v", err)
+	}
+	if c.Job("build") == nil {
+		t.Errorf("ProjectLintResult.Config returned %+v", c)
+	}
+}
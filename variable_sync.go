@@ -0,0 +1,551 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	variableKeyRegexp    = regexp.MustCompile(`^[a-zA-Z0-9_]{1,255}$`)
+	maskedValueRegexp    = regexp.MustCompile(`^[a-zA-Z0-9+/=@:]{8,}$`)
+	dotenvKeyValueRegexp = regexp.MustCompile(`^(?:export\s+)?([a-zA-Z0-9_]+)\s*=\s*(.*)$`)
+)
+
+// VariableSpec represents the desired state of a CI/CD variable.
+type VariableSpec struct {
+	Key              string            `yaml:"key"`
+	Value            string            `yaml:"value"`
+	VariableType     VariableTypeValue `yaml:"variable_type,omitempty"`
+	Protected        bool              `yaml:"protected,omitempty"`
+	Masked           bool              `yaml:"masked,omitempty"`
+	EnvironmentScope string            `yaml:"environment_scope,omitempty"`
+}
+
+// String returns the variable spec without its value, so it is safe to log.
+func (v VariableSpec) String() string {
+	if v.Value != "" {
+		v.Value = "[REDACTED]"
+	}
+	return Stringify(v)
+}
+
+// ParseVariablesDotenv parses variables from a dotenv file. Every line
+// contains a KEY=value pair, optionally prefixed by "export". Values may be
+// single quoted, used literally, or double quoted, in which case \n, \t, \"
+// and \\ are unescaped. Empty lines and lines starting with # are ignored.
+func ParseVariablesDotenv(data []byte) ([]*VariableSpec, error) {
+	var specs []*VariableSpec
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := dotenvKeyValueRegexp.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: expected KEY=value", n)
+		}
+
+		value, err := parseDotenvValue(m[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		specs = append(specs, &VariableSpec{Key: m[1], Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return specs, nil
+}
+
+func parseDotenvValue(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quoted value")
+		}
+		return s[1 : end+1], nil
+
+	case strings.HasPrefix(s, `"`):
+		var b bytes.Buffer
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(s[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double quoted value")
+	}
+
+	// Unquoted values end at an inline comment.
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// ParseVariablesYAML parses variables from a YAML file. The file contains
+// either a list of variables with the same keys as the API, or a mapping
+// from keys to either a value or a mapping with the other attributes.
+//
+//	DEPLOY_HOST: example.com
+//	DEPLOY_TOKEN:
+//	  value: c2VjcmV0LXRva2Vu
+//	  masked: true
+//	  protected: true
+//	  environment_scope: production
+func ParseVariablesYAML(data []byte) ([]*VariableSpec, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var specs []*VariableSpec
+	switch raw := raw.(type) {
+	case nil:
+	case []interface{}:
+		if err := remarshalYAML(raw, &specs); err != nil {
+			return nil, err
+		}
+	case map[interface{}]interface{}:
+		var entries yaml.MapSlice
+		if err := yaml.Unmarshal(data, &entries); err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			spec := &VariableSpec{}
+			switch v := e.Value.(type) {
+			case map[interface{}]interface{}, yaml.MapSlice:
+				if err := remarshalYAML(v, spec); err != nil {
+					return nil, fmt.Errorf("variable %v: %v", e.Key, err)
+				}
+			case nil:
+			default:
+				spec.Value = fmt.Sprint(v)
+			}
+			spec.Key = fmt.Sprint(e.Key)
+			specs = append(specs, spec)
+		}
+	default:
+		return nil, fmt.Errorf("expected a list or a mapping of variables")
+	}
+
+	return specs, nil
+}
+
+// ValidateMaskedValue reports whether value can be masked by GitLab. Masked
+// values must be on a single line, at least 8 characters long and consist
+// only of characters from the Base64 alphabet, @ and :.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ee/ci/variables/#masked-variables
+func ValidateMaskedValue(value string) error {
+	switch {
+	case strings.ContainsAny(value, "\r\n"):
+		return fmt.Errorf("masked values must be on a single line")
+	case len(value) < 8:
+		return fmt.Errorf("masked values must be at least 8 characters long")
+	case !maskedValueRegexp.MatchString(value):
+		return fmt.Errorf("masked values may only contain characters from the Base64 alphabet, @ and :")
+	}
+	return nil
+}
+
+// validateVariableSpecs checks the specs before anything is sent to GitLab.
+// The returned error never contains any values.
+func validateVariableSpecs(specs []*VariableSpec, group bool) error {
+	var problems []string
+	seen := make(map[string]bool)
+
+	for _, v := range specs {
+		scope := v.EnvironmentScope
+		if scope == "" {
+			scope = "*"
+		}
+
+		if !variableKeyRegexp.MatchString(v.Key) {
+			problems = append(problems, fmt.Sprintf("%q: keys may only contain letters, digits and _", v.Key))
+			continue
+		}
+		if group && scope != "*" {
+			problems = append(problems, fmt.Sprintf("%s: group variables have no environment scope", v.Key))
+		}
+		if seen[v.Key+"\x00"+scope] {
+			problems = append(problems, fmt.Sprintf("%s (%s): duplicate variable", v.Key, scope))
+		}
+		seen[v.Key+"\x00"+scope] = true
+
+		switch v.VariableType {
+		case "", EnvVariableType, FileVariableType:
+		default:
+			problems = append(problems, fmt.Sprintf("%s: unknown variable type %q", v.Key, v.VariableType))
+		}
+		if v.Masked {
+			if err := ValidateMaskedValue(v.Value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", v.Key, err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid variables: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// VariableSyncActionValue represents the action taken for a variable by a
+// variable sync.
+type VariableSyncActionValue string
+
+// List of available variable sync actions.
+const (
+	VariableCreate    VariableSyncActionValue = "create"
+	VariableUpdate    VariableSyncActionValue = "update"
+	VariableDelete    VariableSyncActionValue = "delete"
+	VariableUnchanged VariableSyncActionValue = "unchanged"
+)
+
+// VariableChange represents the action taken for a single variable. It
+// never contains the value of the variable.
+type VariableChange struct {
+	Action           VariableSyncActionValue
+	Key              string
+	EnvironmentScope string
+
+	// Fields lists the changed attributes of updated variables.
+	Fields []string
+}
+
+// String returns a single line description of the change.
+func (c *VariableChange) String() string {
+	s := fmt.Sprintf("%s %s", c.Action, c.Key)
+	if c.EnvironmentScope != "" {
+		s += fmt.Sprintf(" (%s)", c.EnvironmentScope)
+	}
+	if len(c.Fields) > 0 {
+		s += ": " + strings.Join(c.Fields, ", ")
+	}
+	return s
+}
+
+// SyncVariablesOptions represents the available SyncVariables() options.
+type SyncVariablesOptions struct {
+	// Prune deletes existing variables that are not in the specs.
+	Prune bool
+
+	// DryRun only computes the changes without applying them.
+	DryRun bool
+}
+
+// SyncVariables makes the variables of a project match the given specs.
+// Variables are identified by their key and environment scope; an empty
+// scope means "*". The specs are validated before any request is made,
+// including the requirements of masked values. If applying a change fails,
+// the changes applied before it are returned with the error.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ee/api/project_level_variables.html
+func (s *ProjectVariablesService) SyncVariables(pid interface{}, specs []*VariableSpec, opt *SyncVariablesOptions, options ...OptionFunc) ([]*VariableChange, error) {
+	if err := validateVariableSpecs(specs, false); err != nil {
+		return nil, err
+	}
+
+	vs := &variableSync{
+		scoped: true,
+		list: func(page int, options []OptionFunc) ([]*VariableSpec, *Response, error) {
+			l, resp, err := s.ListVariables(pid, &ListProjectVariablesOptions{Page: page, PerPage: 100}, options...)
+			if err != nil {
+				return nil, resp, err
+			}
+			var specs []*VariableSpec
+			for _, v := range l {
+				specs = append(specs, &VariableSpec{
+					Key:              v.Key,
+					Value:            v.Value,
+					VariableType:     v.VariableType,
+					Protected:        v.Protected,
+					Masked:           v.Masked,
+					EnvironmentScope: v.EnvironmentScope,
+				})
+			}
+			return specs, resp, nil
+		},
+		create: func(v *VariableSpec, options []OptionFunc) error {
+			_, _, err := s.CreateVariable(pid, &CreateVariableOptions{
+				Key:              String(v.Key),
+				Value:            String(v.Value),
+				VariableType:     VariableType(v.VariableType),
+				Protected:        Bool(v.Protected),
+				Masked:           Bool(v.Masked),
+				EnvironmentScope: String(v.EnvironmentScope),
+			}, options...)
+			return err
+		},
+		update: func(v *VariableSpec, options []OptionFunc) error {
+			_, _, err := s.UpdateVariable(pid, v.Key, &UpdateVariableOptions{
+				Value:            String(v.Value),
+				VariableType:     VariableType(v.VariableType),
+				Protected:        Bool(v.Protected),
+				Masked:           Bool(v.Masked),
+				EnvironmentScope: String(v.EnvironmentScope),
+				Filter:           &VariableFilter{EnvironmentScope: v.EnvironmentScope},
+			}, options...)
+			return err
+		},
+		remove: func(v *VariableSpec, options []OptionFunc) error {
+			_, err := s.RemoveVariable(pid, v.Key, &RemoveProjectVariableOptions{
+				Filter: &VariableFilter{EnvironmentScope: v.EnvironmentScope},
+			}, options...)
+			return err
+		},
+	}
+	return vs.sync(specs, opt, options)
+}
+
+// SyncVariables makes the variables of a group match the given specs.
+// Group variables have no environment scope.
+//
+// GitLab API docs:
+// https://docs.gitlab.com/ee/api/group_level_variables.html
+func (s *GroupVariablesService) SyncVariables(gid interface{}, specs []*VariableSpec, opt *SyncVariablesOptions, options ...OptionFunc) ([]*VariableChange, error) {
+	if err := validateVariableSpecs(specs, true); err != nil {
+		return nil, err
+	}
+
+	vs := &variableSync{
+		list: func(page int, options []OptionFunc) ([]*VariableSpec, *Response, error) {
+			l, resp, err := s.ListVariables(gid, &ListGroupVariablesOptions{Page: page, PerPage: 100}, options...)
+			if err != nil {
+				return nil, resp, err
+			}
+			var specs []*VariableSpec
+			for _, v := range l {
+				specs = append(specs, &VariableSpec{
+					Key:          v.Key,
+					Value:        v.Value,
+					VariableType: v.VariableType,
+					Protected:    v.Protected,
+					Masked:       v.Masked,
+				})
+			}
+			return specs, resp, nil
+		},
+		create: func(v *VariableSpec, options []OptionFunc) error {
+			_, _, err := s.CreateVariable(gid, &CreateGroupVariableOptions{
+				Key:          String(v.Key),
+				Value:        String(v.Value),
+				VariableType: VariableType(v.VariableType),
+				Protected:    Bool(v.Protected),
+				Masked:       Bool(v.Masked),
+			}, options...)
+			return err
+		},
+		update: func(v *VariableSpec, options []OptionFunc) error {
+			_, _, err := s.UpdateVariable(gid, v.Key, &UpdateGroupVariableOptions{
+				Value:        String(v.Value),
+				VariableType: VariableType(v.VariableType),
+				Protected:    Bool(v.Protected),
+				Masked:       Bool(v.Masked),
+			}, options...)
+			return err
+		},
+		remove: func(v *VariableSpec, options []OptionFunc) error {
+			_, err := s.RemoveVariable(gid, v.Key, options...)
+			return err
+		},
+	}
+	return vs.sync(specs, opt, options)
+}
+
+// variableSync applies specs to the variables of a project or group, using
+// the functions of the matching service. Only project variables have an
+// environment scope.
+type variableSync struct {
+	scoped bool
+	list   func(page int, options []OptionFunc) ([]*VariableSpec, *Response, error)
+	create func(v *VariableSpec, options []OptionFunc) error
+	update func(v *VariableSpec, options []OptionFunc) error
+	remove func(v *VariableSpec, options []OptionFunc) error
+}
+
+func (s *variableSync) sync(specs []*VariableSpec, opt *SyncVariablesOptions, options []OptionFunc) ([]*VariableChange, error) {
+	if opt == nil {
+		opt = &SyncVariablesOptions{}
+	}
+
+	current, err := s.listAll(options)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]*VariableSpec)
+	for _, v := range current {
+		existing[s.id(v)] = v
+	}
+
+	var changes []*VariableChange
+	wanted := make(map[string]bool)
+	specFor := make(map[*VariableChange]*VariableSpec)
+
+	for _, v := range specs {
+		want := s.normalize(v)
+		wanted[s.id(want)] = true
+
+		change := &VariableChange{Key: want.Key, EnvironmentScope: want.EnvironmentScope}
+		changes = append(changes, change)
+		specFor[change] = want
+
+		have, ok := existing[s.id(want)]
+		if !ok {
+			change.Action = VariableCreate
+			continue
+		}
+
+		change.Fields = variableSpecDiff(have, want)
+		change.Action = VariableUpdate
+		if len(change.Fields) == 0 {
+			change.Action = VariableUnchanged
+		}
+	}
+
+	if opt.Prune {
+		for _, v := range current {
+			if !wanted[s.id(v)] {
+				change := &VariableChange{
+					Action:           VariableDelete,
+					Key:              v.Key,
+					EnvironmentScope: v.EnvironmentScope,
+				}
+				changes = append(changes, change)
+				specFor[change] = v
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Key != changes[j].Key {
+			return changes[i].Key < changes[j].Key
+		}
+		return changes[i].EnvironmentScope < changes[j].EnvironmentScope
+	})
+
+	if opt.DryRun {
+		return changes, nil
+	}
+
+	for i, c := range changes {
+		var err error
+		switch c.Action {
+		case VariableCreate:
+			err = s.create(specFor[c], options)
+		case VariableUpdate:
+			err = s.update(specFor[c], options)
+		case VariableDelete:
+			err = s.remove(specFor[c], options)
+		}
+		if err != nil {
+			return changes[:i], fmt.Errorf("%s: %v", c, err)
+		}
+	}
+
+	return changes, nil
+}
+
+// id returns the key identifying a variable within the project or group.
+func (s *variableSync) id(v *VariableSpec) string {
+	if !s.scoped {
+		return v.Key
+	}
+	return v.Key + "\x00" + v.EnvironmentScope
+}
+
+func (s *variableSync) normalize(v *VariableSpec) *VariableSpec {
+	n := *v
+	if n.VariableType == "" {
+		n.VariableType = EnvVariableType
+	}
+	n.EnvironmentScope = ""
+	if s.scoped {
+		n.EnvironmentScope = v.EnvironmentScope
+		if n.EnvironmentScope == "" {
+			n.EnvironmentScope = "*"
+		}
+	}
+	return &n
+}
+
+// variableSpecDiff returns the names of the attributes that differ.
+func variableSpecDiff(have, want *VariableSpec) []string {
+	var fields []string
+	if have.Value != want.Value {
+		fields = append(fields, "value")
+	}
+	if have.VariableType != want.VariableType {
+		fields = append(fields, "variable_type")
+	}
+	if have.Protected != want.Protected {
+		fields = append(fields, "protected")
+	}
+	if have.Masked != want.Masked {
+		fields = append(fields, "masked")
+	}
+	return fields
+}
+
+func (s *variableSync) listAll(options []OptionFunc) ([]*VariableSpec, error) {
+	var specs []*VariableSpec
+
+	page := 1
+	for {
+		l, resp, err := s.list(page, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range l {
+			specs = append(specs, s.normalize(v))
+		}
+
+		if resp.NextPage == 0 {
+			return specs, nil
+		}
+		page = resp.NextPage
+	}
+}
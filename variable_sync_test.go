@@ -0,0 +1,204 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVariables(t *testing.T) {
+	dotenv := []byte(`
+# Deployment settings
+export DEPLOY_HOST=example.com # production
+DEPLOY_USER='deploy # user'
+DEPLOY_KEY="line1\nline2"
+`)
+	specs, err := ParseVariablesDotenv(dotenv)
+	if err != nil {
+		t.Fatalf("ParseVariablesDotenv returned error: %v", err)
+	}
+	want := []*VariableSpec{
+		{Key: "DEPLOY_HOST", Value: "example.com"},
+		{Key: "DEPLOY_USER", Value: "deploy # user"},
+		{Key: "DEPLOY_KEY", Value: "line1\nline2"},
+	}
+	if !reflect.DeepEqual(specs, want) {
+		t.Errorf("ParseVariablesDotenv returned %v, want %v", specs, want)
+	}
+	if _, err := ParseVariablesDotenv([]byte(`KEY="unterminated`)); err == nil {
+		t.Errorf("ParseVariablesDotenv returned no error for an unterminated value")
+	}
+
+	yml := []byte(`
+DEPLOY_HOST: example.com
+DEPLOY_PORT: 22
+DEPLOY_TOKEN:
+  value: c2VjcmV0LXRva2Vu
+  masked: true
+  protected: true
+  environment_scope: production
+  variable_type: file
+`)
+	specs, err = ParseVariablesYAML(yml)
+	if err != nil {
+		t.Fatalf("ParseVariablesYAML returned error: %v", err)
+	}
+	want = []*VariableSpec{
+		{Key: "DEPLOY_HOST", Value: "example.com"},
+		{Key: "DEPLOY_PORT", Value: "22"},
+		{Key: "DEPLOY_TOKEN", Value: "c2VjcmV0LXRva2Vu", Masked: true, Protected: true, EnvironmentScope: "production", VariableType: FileVariableType},
+	}
+	if !reflect.DeepEqual(specs, want) {
+		t.Errorf("ParseVariablesYAML returned %v, want %v", specs, want)
+	}
+
+	specs, err = ParseVariablesYAML([]byte("- key: A\n  value: b\n  protected: true\n"))
+	if err != nil {
+		t.Fatalf("ParseVariablesYAML returned error: %v", err)
+	}
+	if len(specs) != 1 || specs[0].Key != "A" || !specs[0].Protected {
+		t.Errorf("ParseVariablesYAML returned %v", specs)
+	}
+
+	if s := want[2].String(); strings.Contains(s, "c2VjcmV0") {
+		t.Errorf("VariableSpec.String() contains the value: %s", s)
+	}
+}
+
+func TestValidateMaskedValue(t *testing.T) {
+	for value, valid := range map[string]bool{
+		"c2VjcmV0LXRva2Vu": true,
+		"user@host:pass":   true,
+		"short":            false,
+		"has spaces in it": false,
+		"two\nlines12345":  false,
+	} {
+		if err := ValidateMaskedValue(value); (err == nil) != valid {
+			t.Errorf("ValidateMaskedValue(%q) returned %v", value, err)
+		}
+	}
+}
+
+func TestSyncProjectVariables(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	var requests []string
+	mux.HandleFunc("/api/v4/projects/1/variables", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `[
+				{"key":"HOST","value":"example.com","variable_type":"env_var","environment_scope":"*"},
+				{"key":"TOKEN","value":"old-token-value","variable_type":"env_var","environment_scope":"production"},
+				{"key":"TOKEN","value":"staging-token","variable_type":"env_var","environment_scope":"staging"},
+				{"key":"OLD","value":"x","variable_type":"env_var","environment_scope":"*"}
+			]`)
+		case "POST":
+			testBody(t, r, `{"key":"CERT","value":"cert","variable_type":"file","protected":false,"masked":false,"environment_scope":"*"}`)
+			fmt.Fprint(w, `{"key":"CERT","value":"cert","variable_type":"file","environment_scope":"*"}`)
+			requests = append(requests, "create CERT")
+		}
+	})
+	mux.HandleFunc("/api/v4/projects/1/variables/TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testBody(t, r, `{"value":"bmV3LXRva2Vu","variable_type":"env_var","protected":true,"masked":true,"environment_scope":"production","filter":{"environment_scope":"production"}}`)
+		fmt.Fprint(w, `{"key":"TOKEN","value":"bmV3LXRva2Vu","variable_type":"env_var","protected":true,"masked":true,"environment_scope":"production"}`)
+		requests = append(requests, "update TOKEN")
+	})
+	mux.HandleFunc("/api/v4/projects/1/variables/OLD", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		if got := r.URL.Query().Get("filter[environment_scope]"); got != "*" {
+			t.Errorf("Request filter is %q, want *", got)
+		}
+		requests = append(requests, "delete OLD")
+	})
+
+	specs := []*VariableSpec{
+		{Key: "HOST", Value: "example.com"},
+		{Key: "TOKEN", Value: "bmV3LXRva2Vu", Protected: true, Masked: true, EnvironmentScope: "production"},
+		{Key: "TOKEN", Value: "staging-token", EnvironmentScope: "staging"},
+		{Key: "CERT", Value: "cert", VariableType: FileVariableType},
+	}
+
+	changes, err := client.ProjectVariables.SyncVariables(1, specs, &SyncVariablesOptions{Prune: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("ProjectVariables.SyncVariables returned error: %v", err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"create CERT (*)",
+		"unchanged HOST (*)",
+		"delete OLD (*)",
+		"update TOKEN (production): value, protected, masked",
+		"unchanged TOKEN (staging)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProjectVariables.SyncVariables returned %q, want %q", got, want)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("ProjectVariables.SyncVariables made requests in a dry run: %v", requests)
+	}
+
+	if _, err := client.ProjectVariables.SyncVariables(1, specs, &SyncVariablesOptions{Prune: true}); err != nil {
+		t.Fatalf("ProjectVariables.SyncVariables returned error: %v", err)
+	}
+	wantRequests := []string{"create CERT", "delete OLD", "update TOKEN"}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("ProjectVariables.SyncVariables made requests %v, want %v", requests, wantRequests)
+	}
+}
+
+func TestSyncGroupVariables(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	var requests []string
+	mux.HandleFunc("/api/v4/groups/1/variables", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testURL(t, r, "/api/v4/groups/1/variables?page=1&per_page=100")
+		fmt.Fprint(w, `[{"key":"HOST","value":"example.com","variable_type":"env_var"}]`)
+	})
+	mux.HandleFunc("/api/v4/groups/1/variables/HOST", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testBody(t, r, `{"value":"example.org","variable_type":"env_var","protected":false,"masked":false}`)
+		fmt.Fprint(w, `{"key":"HOST","value":"example.org","variable_type":"env_var"}`)
+		requests = append(requests, "update HOST")
+	})
+
+	specs := []*VariableSpec{{Key: "HOST", Value: "example.org"}}
+	if _, err := client.GroupVariables.SyncVariables(1, specs, nil); err != nil {
+		t.Fatalf("GroupVariables.SyncVariables returned error: %v", err)
+	}
+	if want := []string{"update HOST"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("GroupVariables.SyncVariables made requests %v, want %v", requests, want)
+	}
+}
+
+func TestSyncVariablesValidation(t *testing.T) {
+	_, server, client := setup()
+	defer teardown(server)
+
+	specs := []*VariableSpec{
+		{Key: "TOKEN", Value: "not masked!", Masked: true},
+		{Key: "SCOPED", Value: "x", EnvironmentScope: "production"},
+		{Key: "BAD-KEY", Value: "x"},
+	}
+
+	_, err := client.GroupVariables.SyncVariables(1, specs, nil)
+	if err == nil {
+		t.Fatalf("GroupVariables.SyncVariables returned no error")
+	}
+	for _, s := range []string{"TOKEN: masked values", "SCOPED: group variables", `"BAD-KEY"`} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("GroupVariables.SyncVariables error %q does not contain %q", err, s)
+		}
+	}
+	if strings.Contains(err.Error(), "not masked!") {
+		t.Errorf("GroupVariables.SyncVariables error contains a value: %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+//
+// Copyright 2019, Sander van Harmelen
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const eventTokenHeader = "X-Gitlab-Token"
+
+// ErrInvalidWebhookToken is returned when a webhook request does not carry
+// one of the currently valid secret tokens.
+var ErrInvalidWebhookToken = errors.New("invalid webhook token")
+
+// WebhookToken returns the secret token of the given webhook request.
+func WebhookToken(r *http.Request) string {
+	return r.Header.Get(eventTokenHeader)
+}
+
+// WebhookSecret represents a webhook secret token. A secret without an
+// expiry date is valid until it is removed.
+type WebhookSecret struct {
+	Token     string
+	ExpiresAt *time.Time
+}
+
+// WebhookSecrets represents the set of currently valid webhook secret
+// tokens. Accepting more than one token allows the token of a hook to be
+// rotated without rejecting events sent with the previous token. The zero
+// value is an empty set ready to use. It is safe for concurrent use.
+type WebhookSecrets struct {
+	mu      sync.RWMutex
+	secrets []*WebhookSecret
+
+	// now is used to determine if secrets are expired and can be replaced
+	// in tests. Defaults to time.Now.
+	now func() time.Time
+}
+
+func (s *WebhookSecrets) timeNow() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+// NewWebhookSecrets returns a new set of webhook secrets.
+func NewWebhookSecrets(secrets ...*WebhookSecret) *WebhookSecrets {
+	s := new(WebhookSecrets)
+	for _, secret := range secrets {
+		s.Add(secret.Token, secret.ExpiresAt)
+	}
+	return s
+}
+
+// Add adds a secret token to the set, replacing the expiry date if the
+// token is already part of it.
+func (s *WebhookSecrets) Add(token string, expiresAt *time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, secret := range s.secrets {
+		if secret.Token == token {
+			secret.ExpiresAt = expiresAt
+			return
+		}
+	}
+	s.secrets = append(s.secrets, &WebhookSecret{Token: token, ExpiresAt: expiresAt})
+}
+
+// Remove removes a secret token from the set.
+func (s *WebhookSecrets) Remove(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, secret := range s.secrets {
+		if secret.Token == token {
+			s.secrets = append(s.secrets[:i], s.secrets[i+1:]...)
+			return
+		}
+	}
+}
+
+// ExpireAllBut sets the expiry date of all secret tokens other than the
+// given one, unless they already expire earlier.
+func (s *WebhookSecrets) ExpireAllBut(token string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, secret := range s.secrets {
+		if secret.Token == token {
+			continue
+		}
+		if secret.ExpiresAt == nil || secret.ExpiresAt.After(expiresAt) {
+			t := expiresAt
+			secret.ExpiresAt = &t
+		}
+	}
+}
+
+// Secrets returns the secret tokens that are currently valid.
+func (s *WebhookSecrets) Secrets() []*WebhookSecret {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.timeNow()
+
+	var secrets []*WebhookSecret
+	for _, secret := range s.secrets {
+		if secret.ExpiresAt == nil || now.Before(*secret.ExpiresAt) {
+			c := *secret
+			secrets = append(secrets, &c)
+		}
+	}
+	return secrets
+}
+
+// Valid reports whether the given token is one of the currently valid
+// secret tokens.
+func (s *WebhookSecrets) Valid(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	valid := false
+	for _, secret := range s.Secrets() {
+		// Compare all secrets in constant time, so the timing does not
+		// reveal which of the secrets matched.
+		if subtle.ConstantTimeCompare([]byte(secret.Token), []byte(token)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// Verify checks that the given webhook request carries one of the
+// currently valid secret tokens.
+//
+// Example usage:
+//
+//	func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//	    if err := s.secrets.Verify(r); err != nil {
+//	        http.Error(w, err.Error(), http.StatusUnauthorized)
+//	        return
+//	    }
+//	    ...
+//	}
+func (s *WebhookSecrets) Verify(r *http.Request) error {
+	if !s.Valid(WebhookToken(r)) {
+		return ErrInvalidWebhookToken
+	}
+	return nil
+}
+
+// RotateHookTokensOptions represents the available RotateHookTokens()
+// options. Token is required.
+type RotateHookTokensOptions struct {
+	// URL selects the hooks to rotate the token of.
+	URL string
+
+	// Projects and Groups are the IDs or paths of the projects and groups
+	// owning the hooks.
+	Projects []interface{}
+	Groups   []interface{}
+
+	// Token is the new secret token.
+	Token string
+
+	// BatchSize is the number of projects and groups updated before
+	// waiting BatchDelay. Defaults to 20.
+	BatchSize  int
+	BatchDelay time.Duration
+
+	// Secrets is the set of secrets used to verify incoming events. When
+	// set, the new token is added before any hook is updated, and all other
+	// tokens are set to expire GracePeriod after the last hook is updated.
+	// GracePeriod must then be positive, as events that are still in flight
+	// may carry an old token.
+	Secrets     *WebhookSecrets
+	GracePeriod time.Duration
+}
+
+// RotateHookTokens sets a new secret token on all hooks with the given URL
+// of the given projects and groups. The hooks are updated in batches, and
+// when a set of secrets is given, both the old and the new tokens are
+// accepted during the transition.
+//
+// A result is returned for every updated hook. Failures are recorded in the
+// results, and the first one is also returned as the error. Old tokens are
+// only expired if all hooks were updated successfully.
+func (s *ProjectsService) RotateHookTokens(opt *RotateHookTokensOptions, options ...OptionFunc) ([]*HookReconcileResult, error) {
+	switch {
+	case opt == nil || opt.Token == "":
+		return nil, fmt.Errorf("a token is required")
+	case opt.Secrets != nil && opt.GracePeriod <= 0:
+		return nil, fmt.Errorf("a positive grace period is required")
+	}
+
+	if opt.Secrets != nil {
+		opt.Secrets.Add(opt.Token, nil)
+	}
+
+	var owners []hookOwner
+	for _, pid := range opt.Projects {
+		owners = append(owners, &projectHookOwner{client: s.client, pid: pid})
+	}
+	for _, gid := range opt.Groups {
+		owners = append(owners, &groupHookOwner{client: s.client, gid: gid})
+	}
+
+	batchSize := opt.BatchSize
+	if batchSize <= 0 {
+		batchSize = 20
+	}
+
+	var results []*HookReconcileResult
+	var err error
+
+	for i, owner := range owners {
+		if i > 0 && i%batchSize == 0 && opt.BatchDelay > 0 {
+			time.Sleep(opt.BatchDelay)
+		}
+
+		for _, r := range rotateHookToken(owner, opt.URL, opt.Token, options...) {
+			if r.Err != nil && err == nil {
+				err = r.Err
+			}
+			results = append(results, r)
+		}
+	}
+
+	if err == nil && opt.Secrets != nil {
+		opt.Secrets.ExpireAllBut(opt.Token, opt.Secrets.timeNow().Add(opt.GracePeriod))
+	}
+
+	return results, err
+}
+
+func rotateHookToken(owner hookOwner, url, token string, options ...OptionFunc) []*HookReconcileResult {
+	var results []*HookReconcileResult
+	result := func(id int, err error) {
+		r := &HookReconcileResult{HookID: id, URL: url, Action: HookUpdated, Err: err}
+		owner.setOwner(r)
+		results = append(results, r)
+	}
+
+	hooks, err := owner.list(options...)
+	if err != nil {
+		result(0, err)
+		return results
+	}
+
+	for _, h := range hooks {
+		if h.spec.URL != url {
+			continue
+		}
+
+		spec := h.spec
+		spec.Token = token
+		result(h.id, owner.edit(h.id, &spec, options...))
+	}
+
+	return results
+}
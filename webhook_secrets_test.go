@@ -0,0 +1,108 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWebhookSecretsVerify(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	valid := now.Add(time.Hour)
+
+	secrets := NewWebhookSecrets(
+		&WebhookSecret{Token: "old", ExpiresAt: &expired},
+		&WebhookSecret{Token: "current", ExpiresAt: &valid},
+		&WebhookSecret{Token: "new"},
+	)
+	secrets.now = func() time.Time { return now }
+
+	for token, want := range map[string]bool{"old": false, "current": true, "new": true, "": false, "other": false} {
+		req, err := http.NewRequest("POST", "https://example.com", nil)
+		if err != nil {
+			t.Fatalf("Error creating HTTP request: %v", err)
+		}
+		req.Header.Set("X-Gitlab-Token", token)
+
+		if got := secrets.Verify(req) == nil; got != want {
+			t.Errorf("WebhookSecrets.Verify(%q) is %v, want %v", token, got, want)
+		}
+	}
+}
+
+func TestWebhookSecretsZeroValue(t *testing.T) {
+	var secrets WebhookSecrets
+	secrets.Add("current", nil)
+
+	if !secrets.Valid("current") {
+		t.Error("WebhookSecrets.Valid(\"current\") is false, want true")
+	}
+
+	secrets.ExpireAllBut("other", time.Now().Add(-time.Hour))
+	if secrets.Valid("current") {
+		t.Error("WebhookSecrets.Valid(\"current\") is true for an expired token, want false")
+	}
+}
+
+func TestRotateHookTokens(t *testing.T) {
+	mux, server, client := setup()
+	defer teardown(server)
+
+	mux.HandleFunc("/api/v4/projects/1/hooks", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[{"id":1,"url":"http://example.com"},{"id":2,"url":"http://other.example.com"}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/hooks/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testBody(t, r, `{"url":"http://example.com","push_events":false,"push_events_branch_filter":"","issues_events":false,"confidential_issues_events":false,"merge_requests_events":false,"tag_push_events":false,"note_events":false,"confidential_note_events":false,"job_events":false,"pipeline_events":false,"wiki_page_events":false,"enable_ssl_verification":false,"token":"new"}`)
+		fmt.Fprint(w, `{"id":1}`)
+	})
+
+	// A zero value set must be usable as well.
+	secrets := new(WebhookSecrets)
+	secrets.Add("old", nil)
+
+	results, err := client.Projects.RotateHookTokens(&RotateHookTokensOptions{
+		URL:         "http://example.com",
+		Projects:    []interface{}{1},
+		Token:       "new",
+		Secrets:     secrets,
+		GracePeriod: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Projects.RotateHookTokens returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].HookID != 1 {
+		t.Errorf("Projects.RotateHookTokens returned %+v, want a single result for hook 1", results)
+	}
+
+	if !secrets.Valid("old") || !secrets.Valid("new") {
+		t.Errorf("WebhookSecrets must accept both tokens during the grace period")
+	}
+
+	secrets.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if secrets.Valid("old") || !secrets.Valid("new") {
+		t.Errorf("WebhookSecrets must only accept the new token after the grace period")
+	}
+}
+
+func TestRotateHookTokensValidation(t *testing.T) {
+	_, server, client := setup()
+	defer teardown(server)
+
+	secrets := NewWebhookSecrets(&WebhookSecret{Token: "old"})
+	for _, opt := range []*RotateHookTokensOptions{
+		nil,
+		{Projects: []interface{}{1}},
+		{Projects: []interface{}{1}, Token: "new", Secrets: secrets},
+	} {
+		if _, err := client.Projects.RotateHookTokens(opt); err == nil {
+			t.Errorf("Projects.RotateHookTokens(%+v) returned no error", opt)
+		}
+	}
+	if secrets.Valid("new") {
+		t.Errorf("Projects.RotateHookTokens added the token of an invalid rotation")
+	}
+}